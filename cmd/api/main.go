@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/exec"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"cs2-stat/internal/database"
 	"cs2-stat/internal/server"
+
+	_ "github.com/joho/godotenv/autoload"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
 	server := server.NewServer()
 
 	done := make(chan bool, 1)
@@ -29,6 +40,27 @@ func main() {
 	log.Println("Graceful shutdown complete.")
 }
 
+// migrate runs `main migrate up|down|status` against DATABASE_URL.
+func migrate(args []string) {
+	if len(args) != 1 || (args[0] != "up" && args[0] != "down" && args[0] != "status") {
+		log.Fatal("usage: migrate up|down|status")
+	}
+
+	dbUrl := os.Getenv("DATABASE_URL")
+	if dbUrl == "" {
+		log.Fatal("DATABASE_URL MUST BE SET")
+	}
+	dbConn, err := sql.Open("sqlite3", dbUrl)
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+	defer dbConn.Close()
+
+	if err := database.Migrate(context.Background(), dbConn, args[0]); err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+}
+
 func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
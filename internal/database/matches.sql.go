@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createMatch = `-- name: CreateMatch :exec
@@ -18,15 +19,24 @@ INSERT INTO matches (
   w_avg_kd,
   w_avg_aim,
   w_avg_utility,
+  w_avg_adr,
   l_avg_leetify_rating,
   l_avg_personal_performance,
   l_avg_hltv_rating,
   l_avg_kd,
   l_avg_aim,
   l_avg_utility,
+  l_avg_adr,
+  region,
+  map,
+  w_rounds,
+  l_rounds,
+  played_at,
+  data_source,
+  tie,
   created_at,
   updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON CONFLICT(match_url) DO UPDATE SET
   match_url = excluded.match_url,
   w_avg_leetify_rating = excluded.w_avg_leetify_rating,
@@ -35,12 +45,21 @@ ON CONFLICT(match_url) DO UPDATE SET
   w_avg_kd = excluded.w_avg_kd,
   w_avg_aim = excluded.w_avg_aim,
   w_avg_utility = excluded.w_avg_utility,
+  w_avg_adr = excluded.w_avg_adr,
   l_avg_leetify_rating = excluded.l_avg_leetify_rating,
   l_avg_personal_performance = excluded.l_avg_personal_performance,
   l_avg_hltv_rating = excluded.l_avg_hltv_rating,
   l_avg_kd = excluded.l_avg_kd,
   l_avg_aim = excluded.l_avg_aim,
   l_avg_utility = excluded.l_avg_utility,
+  l_avg_adr = excluded.l_avg_adr,
+  region = excluded.region,
+  map = COALESCE(excluded.map, matches.map),
+  w_rounds = COALESCE(excluded.w_rounds, matches.w_rounds),
+  l_rounds = COALESCE(excluded.l_rounds, matches.l_rounds),
+  played_at = COALESCE(excluded.played_at, matches.played_at),
+  data_source = COALESCE(excluded.data_source, matches.data_source),
+  tie = excluded.tie,
   updated_at = CURRENT_TIMESTAMP
 `
 
@@ -52,12 +71,21 @@ type CreateMatchParams struct {
 	WAvgKd                  float64
 	WAvgAim                 float64
 	WAvgUtility             float64
+	WAvgAdr                 sql.NullFloat64
 	LAvgLeetifyRating       float64
 	LAvgPersonalPerformance float64
 	LAvgHltvRating          float64
 	LAvgKd                  float64
 	LAvgAim                 float64
 	LAvgUtility             float64
+	LAvgAdr                 sql.NullFloat64
+	Region                  sql.NullString
+	Map                     sql.NullString
+	WRounds                 sql.NullInt64
+	LRounds                 sql.NullInt64
+	PlayedAt                sql.NullTime
+	DataSource              sql.NullString
+	Tie                     bool
 }
 
 func (q *Queries) CreateMatch(ctx context.Context, arg CreateMatchParams) error {
@@ -69,12 +97,207 @@ func (q *Queries) CreateMatch(ctx context.Context, arg CreateMatchParams) error
 		arg.WAvgKd,
 		arg.WAvgAim,
 		arg.WAvgUtility,
+		arg.WAvgAdr,
 		arg.LAvgLeetifyRating,
 		arg.LAvgPersonalPerformance,
 		arg.LAvgHltvRating,
 		arg.LAvgKd,
 		arg.LAvgAim,
 		arg.LAvgUtility,
+		arg.LAvgAdr,
+		arg.Region,
+		arg.Map,
+		arg.WRounds,
+		arg.LRounds,
+		arg.PlayedAt,
+		arg.DataSource,
+		arg.Tie,
 	)
 	return err
 }
+
+const listMatches = `-- name: ListMatches :many
+SELECT m.match_url, m.w_avg_leetify_rating, m.w_avg_personal_performance, m.w_avg_hltv_rating, m.w_avg_kd, m.w_avg_aim, m.w_avg_utility, m.l_avg_leetify_rating, m.l_avg_personal_performance, m.l_avg_hltv_rating, m.l_avg_kd, m.l_avg_aim, m.l_avg_utility, m.created_at, m.updated_at, m.w_avg_adr, m.l_avg_adr, m.region, m.map, m.w_rounds, m.l_rounds, m.played_at, m.data_source, m.tie, s.sort_value
+FROM (
+  SELECT
+    match_url,
+    CAST(CASE CAST(?1 AS TEXT)
+      WHEN 'w_avg_leetify_rating' THEN w_avg_leetify_rating
+      WHEN 'w_avg_personal_performance' THEN w_avg_personal_performance
+      WHEN 'w_avg_hltv_rating' THEN w_avg_hltv_rating
+      WHEN 'w_avg_kd' THEN w_avg_kd
+      WHEN 'w_avg_aim' THEN w_avg_aim
+      WHEN 'w_avg_utility' THEN w_avg_utility
+      WHEN 'w_avg_adr' THEN w_avg_adr
+      WHEN 'l_avg_leetify_rating' THEN l_avg_leetify_rating
+      WHEN 'l_avg_personal_performance' THEN l_avg_personal_performance
+      WHEN 'l_avg_hltv_rating' THEN l_avg_hltv_rating
+      WHEN 'l_avg_kd' THEN l_avg_kd
+      WHEN 'l_avg_aim' THEN l_avg_aim
+      WHEN 'l_avg_utility' THEN l_avg_utility
+      WHEN 'l_avg_adr' THEN l_avg_adr
+      WHEN 'played_at' THEN julianday(played_at)
+      ELSE julianday(created_at)
+    END AS REAL) AS sort_value
+  FROM matches
+  WHERE (?2 IS NULL OR created_at >= ?2)
+    AND (?3 IS NULL OR created_at < ?3)
+    AND (?4 IS NULL OR region = ?4)
+    AND (?5 IS NULL OR map = ?5 COLLATE NOCASE)
+    AND (?6 IS NULL OR played_at >= ?6)
+    AND (?7 IS NULL OR played_at < ?7)
+    AND (?8 IS NULL OR tie = ?8)
+) s
+JOIN matches m ON m.match_url = s.match_url
+WHERE s.sort_value IS NOT NULL
+  AND (?9 IS NULL
+    OR (CAST(?10 AS BOOLEAN) AND (s.sort_value < ?9 OR (s.sort_value = ?9 AND s.match_url < ?11)))
+    OR (NOT CAST(?10 AS BOOLEAN) AND (s.sort_value > ?9 OR (s.sort_value = ?9 AND s.match_url > ?11))))
+ORDER BY
+  CASE WHEN CAST(?10 AS BOOLEAN) THEN s.sort_value END DESC,
+  CASE WHEN CAST(?10 AS BOOLEAN) THEN s.match_url END DESC,
+  s.sort_value ASC,
+  s.match_url ASC
+LIMIT ?12
+`
+
+type ListMatchesParams struct {
+	SortBy        string
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Region        sql.NullString
+	Map           sql.NullString
+	PlayedAfter   sql.NullTime
+	PlayedBefore  sql.NullTime
+	Tie           sql.NullBool
+	CursorValue   sql.NullFloat64
+	SortDesc      bool
+	CursorUrl     string
+	RowLimit      int64
+}
+
+type ListMatchesRow struct {
+	Match     Match
+	SortValue float64
+}
+
+func (q *Queries) ListMatches(ctx context.Context, arg ListMatchesParams) ([]ListMatchesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMatches,
+		arg.SortBy,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Region,
+		arg.Map,
+		arg.PlayedAfter,
+		arg.PlayedBefore,
+		arg.Tie,
+		arg.CursorValue,
+		arg.SortDesc,
+		arg.CursorUrl,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMatchesRow
+	for rows.Next() {
+		var i ListMatchesRow
+		if err := rows.Scan(
+			&i.Match.MatchUrl,
+			&i.Match.WAvgLeetifyRating,
+			&i.Match.WAvgPersonalPerformance,
+			&i.Match.WAvgHltvRating,
+			&i.Match.WAvgKd,
+			&i.Match.WAvgAim,
+			&i.Match.WAvgUtility,
+			&i.Match.LAvgLeetifyRating,
+			&i.Match.LAvgPersonalPerformance,
+			&i.Match.LAvgHltvRating,
+			&i.Match.LAvgKd,
+			&i.Match.LAvgAim,
+			&i.Match.LAvgUtility,
+			&i.Match.CreatedAt,
+			&i.Match.UpdatedAt,
+			&i.Match.WAvgAdr,
+			&i.Match.LAvgAdr,
+			&i.Match.Region,
+			&i.Match.Map,
+			&i.Match.WRounds,
+			&i.Match.LRounds,
+			&i.Match.PlayedAt,
+			&i.Match.DataSource,
+			&i.Match.Tie,
+			&i.SortValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchDifferentials = `-- name: ListMatchDifferentials :many
+WITH decided AS (
+  SELECT * FROM matches
+  WHERE NOT tie
+    AND (?1 IS NULL OR region = ?1)
+    AND (?2 IS NULL OR map = ?2 COLLATE NOCASE)
+)
+SELECT match_url, CAST('adr' AS TEXT) AS metric, CAST(w_avg_adr - l_avg_adr AS REAL) AS delta FROM decided
+UNION ALL
+SELECT match_url, 'leetify_rating', CAST(w_avg_leetify_rating - l_avg_leetify_rating AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'personal_performance', CAST(w_avg_personal_performance - l_avg_personal_performance AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'hltv_rating', CAST(w_avg_hltv_rating - l_avg_hltv_rating AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'kd', CAST(w_avg_kd - l_avg_kd AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'aim', CAST(w_avg_aim - l_avg_aim AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'utility', CAST(w_avg_utility - l_avg_utility AS REAL) FROM decided
+`
+
+type ListMatchDifferentialsParams struct {
+	Region sql.NullString
+	Map    sql.NullString
+}
+
+type ListMatchDifferentialsRow struct {
+	MatchUrl string
+	Metric   string
+	Delta    sql.NullFloat64
+}
+
+// One row per decided match and metric, keyed by the match_players column
+// name. adr goes first so delta is nullable, matches saved before ADR was
+// recorded have none.
+func (q *Queries) ListMatchDifferentials(ctx context.Context, arg ListMatchDifferentialsParams) ([]ListMatchDifferentialsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMatchDifferentials, arg.Region, arg.Map)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMatchDifferentialsRow
+	for rows.Next() {
+		var i ListMatchDifferentialsRow
+		if err := rows.Scan(&i.MatchUrl, &i.Metric, &i.Delta); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
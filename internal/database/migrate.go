@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+	"cs2-stat/sql/schema"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Migrate runs a goose command ("up", "down", "status", ...) against db using
+// the migrations embedded from sql/schema.
+func Migrate(ctx context.Context, db *sql.DB, command string, args ...string) error {
+	goose.SetBaseFS(schema.FS)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		return err
+	}
+	return goose.RunContext(ctx, command, db, ".", args...)
+}
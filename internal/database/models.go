@@ -5,9 +5,29 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 )
 
+type FaceitMatchPlayer struct {
+	MatchID      string
+	PlayerID     string
+	SteamID      sql.NullString
+	Nickname     string
+	Team         int64
+	Won          bool
+	Map          sql.NullString
+	Kills        sql.NullInt64
+	Deaths       sql.NullInt64
+	Assists      sql.NullInt64
+	Kd           sql.NullFloat64
+	Adr          sql.NullFloat64
+	HeadshotsPct sql.NullFloat64
+	FinishedAt   sql.NullTime
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 type Match struct {
 	MatchUrl                string
 	WAvgLeetifyRating       float64
@@ -24,14 +44,78 @@ type Match struct {
 	LAvgUtility             float64
 	CreatedAt               time.Time
 	UpdatedAt               time.Time
+	WAvgAdr                 sql.NullFloat64
+	LAvgAdr                 sql.NullFloat64
+	Region                  sql.NullString
+	Map                     sql.NullString
+	WRounds                 sql.NullInt64
+	LRounds                 sql.NullInt64
+	PlayedAt                sql.NullTime
+	DataSource              sql.NullString
+	Tie                     bool
+}
+
+type MatchPlayer struct {
+	MatchUrl            string
+	PlayerName          string
+	SteamID             sql.NullString
+	Team                int64
+	Won                 bool
+	LeetifyRating       sql.NullFloat64
+	PersonalPerformance sql.NullFloat64
+	HltvRating          sql.NullFloat64
+	Kd                  sql.NullFloat64
+	Adr                 sql.NullFloat64
+	Aim                 sql.NullFloat64
+	Utility             sql.NullFloat64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Tie                 bool
 }
 
 type Player struct {
-	SteamID   interface{}
-	Name      string
-	Country   string
-	FaceitUrl string
-	Avatar    string
+	SteamID             string
+	Name                string
+	Country             string
+	FaceitUrl           string
+	Avatar              string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Region              sql.NullString
+	FaceitElo           sql.NullInt64
+	LeaderboardPosition sql.NullInt64
+}
+
+type PlayerMatch struct {
+	SteamID   string
+	MatchUrl  string
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type PlayerSnapshot struct {
+	SteamID             string
+	CapturedAt          time.Time
+	Region              string
+	FaceitElo           int64
+	SkillLevel          int64
+	LeaderboardPosition int64
+}
+
+type ScrapeError struct {
+	ID         int64
+	RunID      sql.NullInt64
+	MatchUrl   sql.NullString
+	PlayerName sql.NullString
+	ColumnName sql.NullString
+	RawValue   sql.NullString
+	Message    string
+	CreatedAt  time.Time
+}
+
+type ScrapeRun struct {
+	ID           int64
+	StartedAt    time.Time
+	FinishedAt   sql.NullTime
+	Status       string
+	ErrorMessage sql.NullString
 }
@@ -7,26 +7,33 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createPlayer = `-- name: CreatePlayer :one
-INSERT INTO players (steam_id, name, country, faceit_url, avatar, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO players (steam_id, name, country, faceit_url, avatar, region, faceit_elo, leaderboard_position, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON CONFLICT(steam_id) DO UPDATE SET
   name = excluded.name,
   country = excluded.country,
   faceit_url = excluded.faceit_url,
   avatar = excluded.avatar,
+  region = excluded.region,
+  faceit_elo = excluded.faceit_elo,
+  leaderboard_position = excluded.leaderboard_position,
   updated_at = CURRENT_TIMESTAMP
-RETURNING steam_id, name, country, faceit_url, avatar, created_at, updated_at
+RETURNING steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position
 `
 
 type CreatePlayerParams struct {
-	SteamID   interface{}
-	Name      string
-	Country   string
-	FaceitUrl string
-	Avatar    string
+	SteamID             string
+	Name                string
+	Country             string
+	FaceitUrl           string
+	Avatar              string
+	Region              sql.NullString
+	FaceitElo           sql.NullInt64
+	LeaderboardPosition sql.NullInt64
 }
 
 func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (Player, error) {
@@ -36,6 +43,9 @@ func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (Pla
 		arg.Country,
 		arg.FaceitUrl,
 		arg.Avatar,
+		arg.Region,
+		arg.FaceitElo,
+		arg.LeaderboardPosition,
 	)
 	var i Player
 	err := row.Scan(
@@ -46,6 +56,93 @@ func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) (Pla
 		&i.Avatar,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Region,
+		&i.FaceitElo,
+		&i.LeaderboardPosition,
 	)
 	return i, err
 }
+
+const getPlayer = `-- name: GetPlayer :one
+SELECT steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position
+FROM players
+WHERE steam_id = ?
+`
+
+func (q *Queries) GetPlayer(ctx context.Context, steamID string) (Player, error) {
+	row := q.db.QueryRowContext(ctx, getPlayer, steamID)
+	var i Player
+	err := row.Scan(
+		&i.SteamID,
+		&i.Name,
+		&i.Country,
+		&i.FaceitUrl,
+		&i.Avatar,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Region,
+		&i.FaceitElo,
+		&i.LeaderboardPosition,
+	)
+	return i, err
+}
+
+const listPlayers = `-- name: ListPlayers :many
+SELECT steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position
+FROM players
+WHERE (?1 IS NULL OR name LIKE ?1 || '%' ESCAPE '\')
+  AND (?2 IS NULL OR country = ?2)
+  AND (?3 IS NULL
+    OR name > ?3
+    OR (name = ?3 AND steam_id > ?4))
+ORDER BY name, steam_id
+LIMIT ?5
+`
+
+type ListPlayersParams struct {
+	NamePrefix    sql.NullString
+	Country       sql.NullString
+	CursorName    sql.NullString
+	CursorSteamID string
+	RowLimit      int64
+}
+
+func (q *Queries) ListPlayers(ctx context.Context, arg ListPlayersParams) ([]Player, error) {
+	rows, err := q.db.QueryContext(ctx, listPlayers,
+		arg.NamePrefix,
+		arg.Country,
+		arg.CursorName,
+		arg.CursorSteamID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Player
+	for rows.Next() {
+		var i Player
+		if err := rows.Scan(
+			&i.SteamID,
+			&i.Name,
+			&i.Country,
+			&i.FaceitUrl,
+			&i.Avatar,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Region,
+			&i.FaceitElo,
+			&i.LeaderboardPosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StartScrapeHandler serves POST /admin/scrape. The job runs in the
+// background; poll GET /admin/scrape/status for progress.
+func (s *Server) StartScrapeHandler(w http.ResponseWriter, r *http.Request) {
+	// the job outlives the request, so it can't inherit r.Context()
+	ctx, ok := s.scrape.start(context.Background())
+	if !ok {
+		respondWithError(w, http.StatusConflict, errScrapeRunning.Error(), nil)
+		return
+	}
+
+	go func() {
+		if err := s.runStartedScrapeJob(ctx); err != nil {
+			log.Printf("error: %s", err)
+		}
+	}()
+
+	respondWithJSON(w, http.StatusAccepted, s.scrape.snapshot())
+}
+
+// ScrapeStatusHandler serves GET /admin/scrape/status with the progress of the
+// running job, or of the last job if none is running.
+func (s *Server) ScrapeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, s.scrape.snapshot())
+}
+
+// CancelScrapeHandler serves DELETE /admin/scrape. The job stops at its next
+// context check and is recorded as failed.
+func (s *Server) CancelScrapeHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.scrape.stop() {
+		respondWithError(w, http.StatusConflict, "no scrape job running", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, s.scrape.snapshot())
+}
+
+type ScrapeErrorResponse struct {
+	ID        int64     `json:"id"`
+	RunID     *int64    `json:"run_id"`
+	MatchURL  *string   `json:"match_url"`
+	Player    *string   `json:"player"`
+	Column    *string   `json:"column"`
+	Value     *string   `json:"value"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListScrapeErrorsResponse struct {
+	Errors     []ScrapeErrorResponse `json:"errors"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// ListScrapeErrorsHandler serves GET /admin/scrape/errors, newest first. Each
+// error is a match page or scoreboard cell that couldn't be parsed.
+//
+// Query parameters:
+//
+//	run_id  only errors from this scrape run
+//	column  only cells of this match_players column, e.g. "kd"
+//	limit   page size, 1-200 (default 50)
+//	cursor  next_cursor from the previous page
+func (s *Server) ListScrapeErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	params := database.ListScrapeErrorsParams{
+		ColumnName: sql.NullString{String: query.Get("column"), Valid: query.Get("column") != ""},
+		RowLimit:   int64(limit) + 1,
+	}
+	if raw := query.Get("run_id"); raw != "" {
+		runID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "run_id must be an integer", err)
+			return
+		}
+		params.RunID = sql.NullInt64{Int64: runID, Valid: true}
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		beforeID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return
+		}
+		params.BeforeID = sql.NullInt64{Int64: beforeID, Valid: true}
+	}
+
+	rows, err := s.db.ListScrapeErrors(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list scrape errors", err)
+		return
+	}
+
+	resp := ListScrapeErrorsResponse{
+		Errors: []ScrapeErrorResponse{},
+	}
+	if len(rows) > limit {
+		resp.NextCursor = strconv.FormatInt(rows[limit-1].ID, 10)
+		rows = rows[:limit]
+	}
+	for _, row := range rows {
+		resp.Errors = append(resp.Errors, ScrapeErrorResponse{
+			ID:        row.ID,
+			RunID:     nullInt64Ptr(row.RunID),
+			MatchURL:  nullStringPtr(row.MatchUrl),
+			Player:    nullStringPtr(row.PlayerName),
+			Column:    nullStringPtr(row.ColumnName),
+			Value:     nullStringPtr(row.RawValue),
+			Message:   row.Message,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
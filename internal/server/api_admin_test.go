@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAdminRequest builds a request carrying the test server's admin token.
+func newAdminRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func getScrapeStatus(t *testing.T, handler http.Handler) ScrapeStatus {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/status"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+	var status ScrapeStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	return status
+}
+
+func TestAdminScrapeLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	s.faceit = newFaceitStub(t, stubLeaderboard(60))
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+	handler := s.RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodDelete, "/admin/scrape"))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected conflict cancelling with no job; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodPost, "/admin/scrape"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status Accepted; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodPost, "/admin/scrape"))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected conflict starting a second job; got %v", rec.Code)
+	}
+
+	// the first window finishes straight away, then the job pauses
+	deadline := time.Now().Add(time.Second)
+	for getScrapeStatus(t, handler).WindowStart == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := getScrapeStatus(t, handler)
+	if !status.Running || status.WindowStart != 1 || status.WindowEnd != 50 {
+		t.Errorf("expected running job on window 1-50; got %+v", status)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodDelete, "/admin/scrape"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status Accepted; got %v", rec.Code)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for getScrapeStatus(t, handler).Running && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status = getScrapeStatus(t, handler)
+	if status.Running || status.FinishedAt == nil {
+		t.Fatalf("expected job to stop after cancel; got %+v", status)
+	}
+
+	runs, err := s.db.ListScrapeRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("error listing scrape runs. Err: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != scrapeRunFailed {
+		t.Errorf("expected 1 failed scrape run; got %+v", runs)
+	}
+}
+
+func TestAdminRoutesNeedToken(t *testing.T) {
+	s := newTestServer(t)
+	handler := s.RegisterRoutes()
+
+	for _, auth := range []string{"", "Bearer wrong-token", testAdminToken} {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodPost, "/admin/scrape", nil),
+			httptest.NewRequest(http.MethodGet, "/admin/scrape/status", nil),
+			httptest.NewRequest(http.MethodDelete, "/admin/scrape", nil),
+			httptest.NewRequest(http.MethodGet, "/admin/scrape/errors", nil),
+		} {
+			req.Header.Set("Authorization", auth)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with %q: expected status Unauthorized; got %v", req.Method, req.URL, auth, rec.Code)
+			}
+			if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+				t.Errorf("%s %s: expected no CORS header on admin routes; got %q", req.Method, req.URL, origin)
+			}
+		}
+	}
+
+	s.adminToken = ""
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/status"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected admin routes closed without ADMIN_TOKEN; got %v", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/players", nil))
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("expected CORS headers on the public API")
+	}
+}
+
+func TestListScrapeErrorsHandler(t *testing.T) {
+	s := newTestServer(t)
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+	if _, err := s.scrapeAndSaveMatches(context.Background(), "EU", []string{leetifyUserURL + "76561198000000002"}); err != nil {
+		t.Fatalf("error scraping fixtures. Err: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/errors?column=kd"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+	var resp ListScrapeErrorsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if len(resp.Errors) != 1 {
+		t.Fatalf("expected the bad-1 K/D error; got %+v", resp.Errors)
+	}
+	if e := resp.Errors[0]; *e.MatchURL != "https://leetify.com/app/match-details/bad-1" || *e.Player != "win2" || *e.Value != "1.3x" || e.RunID != nil {
+		t.Errorf("unexpected scrape error %+v", e)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/errors?limit=2"))
+	resp = ListScrapeErrorsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if len(resp.Errors) != 2 || resp.NextCursor == "" {
+		t.Fatalf("expected two errors and a cursor; got %+v", resp)
+	}
+	if e := resp.Errors[1]; *e.MatchURL != "https://leetify.com/app/match-details/short-1" || e.Column != nil {
+		t.Errorf("expected the short-1 match error second; got %+v", e)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/errors?limit=2&cursor="+resp.NextCursor))
+	resp = ListScrapeErrorsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if len(resp.Errors) != 1 || resp.NextCursor != "" || *resp.Errors[0].MatchURL != "https://leetify.com/app/match-details/bad-1" || resp.Errors[0].Column == nil || *resp.Errors[0].Column != "kd" {
+		t.Errorf("expected the older bad-1 K/D error alone on the last page; got %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, newAdminRequest(http.MethodGet, "/admin/scrape/errors?run_id=abc"))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for bad run_id; got %v", rec.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"cs2-stat/internal/database"
+	"math"
+	"net/http"
+)
+
+type DifferentialStats struct {
+	Metric         string  `json:"metric"`
+	Samples        int     `json:"samples"`
+	Mean           float64 `json:"mean"`
+	Median         float64 `json:"median"`
+	StdDev         float64 `json:"stddev"`
+	WinnerLedShare float64 `json:"winner_led_share"`
+}
+
+type DifferentialsResponse struct {
+	Matches int                 `json:"matches"`
+	Metrics []DifferentialStats `json:"metrics"`
+}
+
+// DifferentialsHandler serves GET /api/insights/differentials. For each metric
+// it summarizes the winning team average minus the losing team average across
+// every decided match, or only those from ?region= and on ?map= if given. Ties
+// have no winning side and are left out.
+func (s *Server) DifferentialsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	region, err := parseRegionParam(query.Get("region"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := s.db.ListMatchDifferentials(r.Context(), database.ListMatchDifferentialsParams{
+		Region: region,
+		Map:    parseMapParam(query.Get("map")),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get match differentials", err)
+		return
+	}
+
+	deltas := make(map[string][]float64, len(metrics))
+	decided := make(map[string]bool)
+	for _, row := range rows {
+		decided[row.MatchUrl] = true
+		// matches saved before ADR was recorded have no ADR delta
+		if row.Delta.Valid {
+			deltas[row.Metric] = append(deltas[row.Metric], row.Delta.Float64)
+		}
+	}
+
+	resp := DifferentialsResponse{Matches: len(decided)}
+	for _, metric := range metrics {
+		resp.Metrics = append(resp.Metrics, differentialStats(metric.Name, deltas[metric.Name]))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// differentialStats summarizes win-minus-loss deltas. WinnerLedShare is the
+// fraction of deltas above zero. Unlike a team's Aggregate, which covers every
+// player of the team, the matches are a sample of all matches played, so
+// StdDev is the sample standard deviation.
+func differentialStats(metric string, deltas []float64) DifferentialStats {
+	agg := aggregate(deltas)
+	stats := DifferentialStats{Metric: metric, Samples: agg.N, Mean: agg.Mean, Median: agg.Median}
+	if agg.N == 0 {
+		return stats
+	}
+
+	var led int
+	for _, d := range deltas {
+		if d > 0 {
+			led++
+		}
+	}
+	stats.WinnerLedShare = float64(led) / float64(agg.N)
+	if agg.N > 1 {
+		stats.StdDev = agg.StdDev * math.Sqrt(float64(agg.N)/float64(agg.N-1))
+	}
+
+	return stats
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDifferentialStats(t *testing.T) {
+	stats := differentialStats("kd", []float64{0.5, -0.25, 1, 0.75})
+	expected := DifferentialStats{
+		Metric:         "kd",
+		Samples:        4,
+		Mean:           0.5,
+		Median:         0.625,
+		StdDev:         math.Sqrt(0.875 / 3),
+		WinnerLedShare: 0.75,
+	}
+	if math.Abs(stats.StdDev-expected.StdDev) > 1e-9 {
+		t.Errorf("expected stddev %v; got %v", expected.StdDev, stats.StdDev)
+	}
+	stats.StdDev = expected.StdDev
+	if stats != expected {
+		t.Errorf("expected %+v; got %+v", expected, stats)
+	}
+
+	if empty := differentialStats("aim", nil); empty != (DifferentialStats{Metric: "aim"}) {
+		t.Errorf("expected zero stats for no matches; got %+v", empty)
+	}
+}
+
+func TestDifferentialsHandler(t *testing.T) {
+	s := newTestServer(t)
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: "a", WAvgLeetifyRating: 2, LAvgLeetifyRating: 1, WAvgAim: 60, LAvgAim: 70},
+		{MatchUrl: "b", WAvgLeetifyRating: 4, LAvgLeetifyRating: 1, WAvgAim: 80, LAvgAim: 70},
+		{MatchUrl: "tie", WAvgLeetifyRating: 1, LAvgLeetifyRating: 9, WAvgAim: 10, LAvgAim: 90, Tie: true},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/insights/differentials", nil)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+
+	var resp DifferentialsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if resp.Matches != 2 || len(resp.Metrics) != 7 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if m := resp.Metrics[0]; m.Metric != "leetify_rating" || m.Mean != 2 || m.WinnerLedShare != 1 {
+		t.Errorf("unexpected leetify_rating stats %+v", m)
+	}
+	if m := resp.Metrics[4]; m.Metric != "adr" || m.Samples != 0 {
+		t.Errorf("expected no ADR deltas for matches without ADR; got %+v", m)
+	}
+	if m := resp.Metrics[5]; m.Metric != "aim" || m.Mean != 0 || m.WinnerLedShare != 0.5 {
+		t.Errorf("unexpected aim stats %+v", m)
+	}
+}
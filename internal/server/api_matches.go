@@ -0,0 +1,336 @@
+package server
+
+import (
+	"cs2-stat/internal/database"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// matchSortColumns are the matches columns that can be passed as ?sort=.
+// An empty sort orders by created_at.
+var matchSortColumns = map[string]bool{
+	"created_at":                 true,
+	"w_avg_leetify_rating":       true,
+	"w_avg_personal_performance": true,
+	"w_avg_hltv_rating":          true,
+	"w_avg_kd":                   true,
+	"w_avg_aim":                  true,
+	"w_avg_utility":              true,
+	"w_avg_adr":                  true,
+	"l_avg_leetify_rating":       true,
+	"l_avg_personal_performance": true,
+	"l_avg_hltv_rating":          true,
+	"l_avg_kd":                   true,
+	"l_avg_aim":                  true,
+	"l_avg_utility":              true,
+	"l_avg_adr":                  true,
+	"played_at":                  true,
+}
+
+type MatchResponse struct {
+	MatchURL                   string     `json:"match_url"`
+	WinAvgLeetifyRating        float64    `json:"w_avg_leetify_rating"`
+	WinAvgPersonalPerformance  float64    `json:"w_avg_personal_performance"`
+	WinAvgHLTVRating           float64    `json:"w_avg_hltv_rating"`
+	WinAvgKD                   float64    `json:"w_avg_kd"`
+	WinAvgAim                  float64    `json:"w_avg_aim"`
+	WinAvgUtility              float64    `json:"w_avg_utility"`
+	WinAvgADR                  *float64   `json:"w_avg_adr"`
+	LossAvgLeetifyRating       float64    `json:"l_avg_leetify_rating"`
+	LossAvgPersonalPerformance float64    `json:"l_avg_personal_performance"`
+	LossAvgHLTVRating          float64    `json:"l_avg_hltv_rating"`
+	LossAvgKD                  float64    `json:"l_avg_kd"`
+	LossAvgAim                 float64    `json:"l_avg_aim"`
+	LossAvgUtility             float64    `json:"l_avg_utility"`
+	LossAvgADR                 *float64   `json:"l_avg_adr"`
+	Region                     *string    `json:"region"`
+	Map                        *string    `json:"map"`
+	WinRounds                  *int64     `json:"w_rounds"`
+	LossRounds                 *int64     `json:"l_rounds"`
+	PlayedAt                   *time.Time `json:"played_at"`
+	DataSource                 *string    `json:"data_source"`
+	Tie                        bool       `json:"tie"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+type ListMatchesResponse struct {
+	Matches    []MatchResponse `json:"matches"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// matchCursor is the position of the last row of a page. It carries the sort
+// it was issued for so a cursor can't be replayed against a different order.
+type matchCursor struct {
+	Sort  string  `json:"s"`
+	Desc  bool    `json:"d"`
+	Value float64 `json:"v"`
+	URL   string  `json:"u"`
+}
+
+func (c matchCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeMatchCursor(raw string) (matchCursor, error) {
+	var c matchCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// ListMatchesHandler serves GET /api/matches. The w_ and l_ columns of a tied
+// match hold its two teams in scoreboard order.
+//
+// Query parameters:
+//
+//	sort         one of matchSortColumns (default created_at); sorting by an
+//	             ADR column or played_at skips matches without that value
+//	order        asc or desc (default desc)
+//	from         only matches created at or after this RFC 3339 time
+//	to           only matches created before this RFC 3339 time
+//	played_from  only matches played at or after this RFC 3339 time
+//	played_to    only matches played before this RFC 3339 time
+//	region       only matches scraped from this Faceit region's leaderboard
+//	map          only matches on this map, case-insensitive, e.g. "mirage"
+//	tie          true for only tied matches, false for only decided ones
+//	limit        page size, 1-200 (default 50)
+//	cursor       next_cursor from the previous page
+func (s *Server) ListMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if !matchSortColumns[sortBy] {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid sort column: %s", sortBy), nil)
+		return
+	}
+
+	var desc bool
+	switch query.Get("order") {
+	case "", "desc":
+		desc = true
+	case "asc":
+		desc = false
+	default:
+		respondWithError(w, http.StatusBadRequest, "order must be asc or desc", nil)
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	createdAfter, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "from must be an RFC 3339 timestamp", err)
+		return
+	}
+	createdBefore, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "to must be an RFC 3339 timestamp", err)
+		return
+	}
+
+	playedAfter, err := parseTimeParam(query.Get("played_from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "played_from must be an RFC 3339 timestamp", err)
+		return
+	}
+	playedBefore, err := parseTimeParam(query.Get("played_to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "played_to must be an RFC 3339 timestamp", err)
+		return
+	}
+
+	region, err := parseRegionParam(query.Get("region"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tie, err := parseBoolParam(query.Get("tie"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "tie must be true or false", err)
+		return
+	}
+
+	params := database.ListMatchesParams{
+		SortBy:        sortBy,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Region:        region,
+		Map:           parseMapParam(query.Get("map")),
+		PlayedAfter:   playedAfter,
+		PlayedBefore:  playedBefore,
+		Tie:           tie,
+		SortDesc:      desc,
+		RowLimit:      int64(limit) + 1,
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodeMatchCursor(raw)
+		if err != nil || cursor.Sort != sortBy || cursor.Desc != desc {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return
+		}
+		params.CursorValue = sql.NullFloat64{Float64: cursor.Value, Valid: true}
+		params.CursorUrl = cursor.URL
+	}
+
+	rows, err := s.db.ListMatches(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list matches", err)
+		return
+	}
+
+	resp := ListMatchesResponse{
+		Matches: []MatchResponse{},
+	}
+	if len(rows) > limit {
+		last := rows[limit-1]
+		resp.NextCursor = matchCursor{
+			Sort:  sortBy,
+			Desc:  desc,
+			Value: last.SortValue,
+			URL:   last.Match.MatchUrl,
+		}.encode()
+		rows = rows[:limit]
+	}
+	for _, row := range rows {
+		resp.Matches = append(resp.Matches, matchToResponse(row.Match))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func matchToResponse(match database.Match) MatchResponse {
+	return MatchResponse{
+		MatchURL:                   match.MatchUrl,
+		WinAvgLeetifyRating:        match.WAvgLeetifyRating,
+		WinAvgPersonalPerformance:  match.WAvgPersonalPerformance,
+		WinAvgHLTVRating:           match.WAvgHltvRating,
+		WinAvgKD:                   match.WAvgKd,
+		WinAvgAim:                  match.WAvgAim,
+		WinAvgUtility:              match.WAvgUtility,
+		WinAvgADR:                  nullFloatPtr(match.WAvgAdr),
+		LossAvgLeetifyRating:       match.LAvgLeetifyRating,
+		LossAvgPersonalPerformance: match.LAvgPersonalPerformance,
+		LossAvgHLTVRating:          match.LAvgHltvRating,
+		LossAvgKD:                  match.LAvgKd,
+		LossAvgAim:                 match.LAvgAim,
+		LossAvgUtility:             match.LAvgUtility,
+		LossAvgADR:                 nullFloatPtr(match.LAvgAdr),
+		Region:                     nullStringPtr(match.Region),
+		Map:                        nullStringPtr(match.Map),
+		WinRounds:                  nullInt64Ptr(match.WRounds),
+		LossRounds:                 nullInt64Ptr(match.LRounds),
+		PlayedAt:                   nullTimePtr(match.PlayedAt),
+		DataSource:                 nullStringPtr(match.DataSource),
+		Tie:                        match.Tie,
+		CreatedAt:                  match.CreatedAt,
+		UpdatedAt:                  match.UpdatedAt,
+	}
+}
+
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	return limit, nil
+}
+
+func parseTimeParam(raw string) (sql.NullTime, error) {
+	if raw == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func parseRegionParam(raw string) (sql.NullString, error) {
+	if raw == "" {
+		return sql.NullString{}, nil
+	}
+	region, ok := parseRegion(raw)
+	if !ok {
+		return sql.NullString{}, fmt.Errorf("region must be one of %s", strings.Join(faceitRegions, ", "))
+	}
+	return sql.NullString{String: region, Valid: true}, nil
+}
+
+func parseBoolParam(raw string) (sql.NullBool, error) {
+	if raw == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return sql.NullBool{}, err
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// parseMapParam accepts a map as "de_mirage" or as Leetify shows it,
+// "Mirage".
+func parseMapParam(raw string) sql.NullString {
+	name := normalizeMapName(raw)
+	return sql.NullString{String: name, Valid: name != ""}
+}
+
+// nullFloatPtr maps NULL to a JSON null.
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+// nullStringPtr maps NULL to a JSON null.
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// nullInt64Ptr maps NULL to a JSON null.
+func nullInt64Ptr(i sql.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
+// nullTimePtr maps NULL to a JSON null.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func insertTestMatches(t *testing.T, s *Server, n int) {
+	t.Helper()
+	var matches []database.CreateMatchParams
+	for i := range n {
+		matches = append(matches, database.CreateMatchParams{
+			MatchUrl: fmt.Sprintf("https://leetify.com/app/match-details/%02d", i),
+			WAvgKd:   float64(i % 3),
+		})
+	}
+	if err := BatchInsertMatches(context.Background(), s.dbConn, matches, nil, nil); err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+}
+
+func getMatches(t *testing.T, s *Server, query string) (int, ListMatchesResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/matches?"+query, nil)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	var resp ListMatchesResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("error decoding response. Err: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestListMatchesPagination(t *testing.T) {
+	s := newTestServer(t)
+	insertTestMatches(t, s, 10)
+
+	for _, order := range []string{"asc", "desc"} {
+		seen := map[string]bool{}
+		prevKD := -1.0
+		if order == "desc" {
+			prevKD = 100
+		}
+		cursor := ""
+		pages := 0
+		for {
+			code, resp := getMatches(t, s, "sort=w_avg_kd&limit=3&order="+order+"&cursor="+cursor)
+			if code != http.StatusOK {
+				t.Fatalf("expected status OK; got %v", code)
+			}
+			pages++
+			for _, m := range resp.Matches {
+				if seen[m.MatchURL] {
+					t.Errorf("%s: match %s returned twice", order, m.MatchURL)
+				}
+				seen[m.MatchURL] = true
+				if (order == "asc" && m.WinAvgKD < prevKD) || (order == "desc" && m.WinAvgKD > prevKD) {
+					t.Errorf("%s: matches out of order: %v after %v", order, m.WinAvgKD, prevKD)
+				}
+				prevKD = m.WinAvgKD
+			}
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+		if len(seen) != 10 {
+			t.Errorf("%s: expected 10 matches; got %d", order, len(seen))
+		}
+		if pages != 4 {
+			t.Errorf("%s: expected 4 pages; got %d", order, pages)
+		}
+	}
+}
+
+func TestListMatchesCreatedAtFilter(t *testing.T) {
+	s := newTestServer(t)
+	insertTestMatches(t, s, 2)
+
+	_, resp := getMatches(t, s, "from=2000-01-01T00:00:00Z")
+	if len(resp.Matches) != 2 {
+		t.Errorf("expected 2 matches after 2000; got %d", len(resp.Matches))
+	}
+	_, resp = getMatches(t, s, "to=2000-01-01T00:00:00Z")
+	if len(resp.Matches) != 0 {
+		t.Errorf("expected 0 matches before 2000; got %d", len(resp.Matches))
+	}
+}
+
+func TestListMatchesBadRequest(t *testing.T) {
+	s := newTestServer(t)
+	for _, query := range []string{
+		"sort=match_url",
+		"order=up",
+		"limit=0",
+		"from=yesterday",
+		"cursor=not-a-cursor",
+		"region=mars",
+		"played_from=last-week",
+		"tie=maybe",
+	} {
+		if code, _ := getMatches(t, s, query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400; got %v", query, code)
+		}
+	}
+}
+
+func TestListMatchesSortByADR(t *testing.T) {
+	s := newTestServer(t)
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: "old"},
+		{MatchUrl: "low", WAvgAdr: sql.NullFloat64{Float64: 70, Valid: true}},
+		{MatchUrl: "high", WAvgAdr: sql.NullFloat64{Float64: 95, Valid: true}},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	_, resp := getMatches(t, s, "sort=w_avg_adr")
+	if len(resp.Matches) != 2 {
+		t.Fatalf("expected matches without ADR to be skipped; got %d matches", len(resp.Matches))
+	}
+	if resp.Matches[0].MatchURL != "high" || *resp.Matches[0].WinAvgADR != 95 {
+		t.Errorf("unexpected first match %+v", resp.Matches[0])
+	}
+
+	_, resp = getMatches(t, s, "")
+	for _, m := range resp.Matches {
+		if m.MatchURL == "old" && m.WinAvgADR != nil {
+			t.Errorf("expected null ADR for match without ADR; got %v", *m.WinAvgADR)
+		}
+	}
+}
+
+func TestListMatchesRegionFilter(t *testing.T) {
+	s := newTestServer(t)
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: "eu", Region: sql.NullString{String: "EU", Valid: true}},
+		{MatchUrl: "na", Region: sql.NullString{String: "NA", Valid: true}},
+		{MatchUrl: "unknown"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	_, resp := getMatches(t, s, "region=na")
+	if len(resp.Matches) != 1 || resp.Matches[0].MatchURL != "na" || *resp.Matches[0].Region != "NA" {
+		t.Errorf("expected only the NA match; got %+v", resp.Matches)
+	}
+	_, resp = getMatches(t, s, "")
+	if len(resp.Matches) != 3 {
+		t.Errorf("expected 3 matches without a region filter; got %d", len(resp.Matches))
+	}
+}
+
+func TestListMatchesMapAndPlayedAtFilter(t *testing.T) {
+	s := newTestServer(t)
+	played := time.Date(2025, 7, 14, 20, 0, 0, 0, time.UTC)
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: "mirage-old", Map: sql.NullString{String: "de_mirage", Valid: true}, PlayedAt: sql.NullTime{Time: played.AddDate(0, 0, -7), Valid: true}},
+		{MatchUrl: "mirage-new", Map: sql.NullString{String: "de_mirage", Valid: true}, PlayedAt: sql.NullTime{Time: played, Valid: true}},
+		{MatchUrl: "nuke", Map: sql.NullString{String: "de_nuke", Valid: true}, PlayedAt: sql.NullTime{Time: played, Valid: true}},
+		{MatchUrl: "unknown"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	_, resp := getMatches(t, s, "map=Mirage&sort=played_at")
+	if len(resp.Matches) != 2 || resp.Matches[0].MatchURL != "mirage-new" || *resp.Matches[0].Map != "de_mirage" {
+		t.Errorf("expected Mirage matches newest first; got %+v", resp.Matches)
+	}
+	_, resp = getMatches(t, s, "map=de_mirage&played_from=2025-07-10T00:00:00Z")
+	if len(resp.Matches) != 1 || resp.Matches[0].MatchURL != "mirage-new" {
+		t.Errorf("expected only the recent Mirage match; got %+v", resp.Matches)
+	}
+	_, resp = getMatches(t, s, "sort=played_at")
+	if len(resp.Matches) != 3 {
+		t.Errorf("expected matches without played_at skipped when sorting by it; got %d", len(resp.Matches))
+	}
+}
+
+func TestListMatchesTieFilter(t *testing.T) {
+	s := newTestServer(t)
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: "win"},
+		{MatchUrl: "tie", Tie: true},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	_, resp := getMatches(t, s, "tie=true")
+	if len(resp.Matches) != 1 || resp.Matches[0].MatchURL != "tie" || !resp.Matches[0].Tie {
+		t.Errorf("expected only the tied match; got %+v", resp.Matches)
+	}
+	_, resp = getMatches(t, s, "tie=false")
+	if len(resp.Matches) != 1 || resp.Matches[0].MatchURL != "win" || resp.Matches[0].Tie {
+		t.Errorf("expected only the decided match; got %+v", resp.Matches)
+	}
+	_, resp = getMatches(t, s, "")
+	if len(resp.Matches) != 2 {
+		t.Errorf("expected 2 matches without a tie filter; got %d", len(resp.Matches))
+	}
+}
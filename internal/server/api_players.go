@@ -0,0 +1,413 @@
+package server
+
+import (
+	"cs2-stat/internal/database"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type PlayerResponse struct {
+	SteamID             string    `json:"steam_id"`
+	Name                string    `json:"name"`
+	Country             string    `json:"country"`
+	FaceitURL           string    `json:"faceit_url"`
+	Avatar              string    `json:"avatar"`
+	Region              *string   `json:"region"`
+	FaceitElo           *int64    `json:"faceit_elo"`
+	LeaderboardPosition *int64    `json:"leaderboard_position"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type ListPlayersResponse struct {
+	Players    []PlayerResponse `json:"players"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type PlayerSnapshotResponse struct {
+	CapturedAt          time.Time `json:"captured_at"`
+	Region              string    `json:"region"`
+	FaceitElo           int64     `json:"faceit_elo"`
+	SkillLevel          int64     `json:"skill_level"`
+	LeaderboardPosition int64     `json:"leaderboard_position"`
+}
+
+type EloTimelineResponse struct {
+	SteamID   string                   `json:"steam_id"`
+	Snapshots []PlayerSnapshotResponse `json:"snapshots"`
+}
+
+type FaceitMatchResponse struct {
+	MatchID      string     `json:"match_id"`
+	Map          *string    `json:"map"`
+	Won          bool       `json:"won"`
+	Kills        *int64     `json:"kills"`
+	Deaths       *int64     `json:"deaths"`
+	Assists      *int64     `json:"assists"`
+	KD           *float64   `json:"kd"`
+	ADR          *float64   `json:"adr"`
+	HeadshotsPct *float64   `json:"headshots_pct"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+// SourceStatsResponse averages a player's stats over their recent matches
+// from one source. An average is null when no match has the stat.
+type SourceStatsResponse struct {
+	Matches int      `json:"matches"`
+	KD      *float64 `json:"avg_kd"`
+	ADR     *float64 `json:"avg_adr"`
+}
+
+type FaceitMatchesResponse struct {
+	SteamID string                `json:"steam_id"`
+	Matches []FaceitMatchResponse `json:"matches"`
+	Faceit  SourceStatsResponse   `json:"faceit"`
+	Leetify SourceStatsResponse   `json:"leetify"`
+}
+
+type playerCursor struct {
+	Name    string `json:"n"`
+	SteamID string `json:"s"`
+}
+
+func (c playerCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePlayerCursor(raw string) (playerCursor, error) {
+	var c playerCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// likeEscaper escapes LIKE wildcards so a search for "a_b" doesn't match "axb".
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListPlayersHandler serves GET /api/players, ordered by name.
+//
+// Query parameters:
+//
+//	name     case-insensitive name prefix
+//	country  two letter country code as stored from Faceit, e.g. "dk"
+//	limit    page size, 1-200 (default 50)
+//	cursor   next_cursor from the previous page
+func (s *Server) ListPlayersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	params := database.ListPlayersParams{
+		RowLimit: int64(limit) + 1,
+	}
+	if name := query.Get("name"); name != "" {
+		params.NamePrefix = sql.NullString{String: likeEscaper.Replace(name), Valid: true}
+	}
+	if country := query.Get("country"); country != "" {
+		params.Country = sql.NullString{String: strings.ToLower(country), Valid: true}
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodePlayerCursor(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return
+		}
+		params.CursorName = sql.NullString{String: cursor.Name, Valid: true}
+		params.CursorSteamID = cursor.SteamID
+	}
+
+	players, err := s.db.ListPlayers(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list players", err)
+		return
+	}
+
+	resp := ListPlayersResponse{
+		Players: []PlayerResponse{},
+	}
+	if len(players) > limit {
+		last := players[limit-1]
+		resp.NextCursor = playerCursor{
+			Name:    last.Name,
+			SteamID: last.SteamID,
+		}.encode()
+		players = players[:limit]
+	}
+	for _, player := range players {
+		resp.Players = append(resp.Players, playerToResponse(player))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// GetPlayerHandler serves GET /api/players/{steamID}.
+func (s *Server) GetPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	steamID := r.PathValue("steamID")
+
+	player, err := s.db.GetPlayer(r.Context(), steamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "player not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get player", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, playerToResponse(player))
+}
+
+// GetPlayerEloHandler serves GET /api/players/{steamID}/elo, the player's
+// Faceit Elo, skill level and leaderboard position at each ingestion, oldest
+// first.
+//
+// Query parameters:
+//
+//	from  only snapshots taken at or after this RFC 3339 time
+//	to    only snapshots taken before this RFC 3339 time
+func (s *Server) GetPlayerEloHandler(w http.ResponseWriter, r *http.Request) {
+	steamID := r.PathValue("steamID")
+	query := r.URL.Query()
+
+	capturedAfter, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "from must be an RFC 3339 timestamp", err)
+		return
+	}
+	capturedBefore, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "to must be an RFC 3339 timestamp", err)
+		return
+	}
+
+	_, err = s.db.GetPlayer(r.Context(), steamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "player not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get player", err)
+		return
+	}
+
+	snapshots, err := s.db.ListPlayerSnapshots(r.Context(), database.ListPlayerSnapshotsParams{
+		SteamID:        steamID,
+		CapturedAfter:  capturedAfter,
+		CapturedBefore: capturedBefore,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list player snapshots", err)
+		return
+	}
+
+	resp := EloTimelineResponse{
+		SteamID:   steamID,
+		Snapshots: []PlayerSnapshotResponse{},
+	}
+	for _, snapshot := range snapshots {
+		resp.Snapshots = append(resp.Snapshots, PlayerSnapshotResponse{
+			CapturedAt:          snapshot.CapturedAt,
+			Region:              snapshot.Region,
+			FaceitElo:           snapshot.FaceitElo,
+			SkillLevel:          snapshot.SkillLevel,
+			LeaderboardPosition: snapshot.LeaderboardPosition,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListPlayerMatchesHandler serves GET /api/players/{steamID}/matches, the
+// matches found on the player's Leetify profile, newest first.
+//
+// Query parameters:
+//
+//	limit   page size, 1-200 (default 50)
+//	cursor  next_cursor from the previous page
+func (s *Server) ListPlayerMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	steamID := r.PathValue("steamID")
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	params := database.ListPlayerMatchesParams{
+		SteamID:  steamID,
+		RowLimit: int64(limit) + 1,
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := decodeMatchCursor(raw)
+		if err != nil || cursor.Sort != "created_at" || !cursor.Desc {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return
+		}
+		params.CursorValue = sql.NullFloat64{Float64: cursor.Value, Valid: true}
+		params.CursorUrl = cursor.URL
+	}
+
+	_, err = s.db.GetPlayer(r.Context(), steamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "player not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get player", err)
+		return
+	}
+
+	rows, err := s.db.ListPlayerMatches(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list player matches", err)
+		return
+	}
+
+	resp := ListMatchesResponse{
+		Matches: []MatchResponse{},
+	}
+	if len(rows) > limit {
+		last := rows[limit-1]
+		resp.NextCursor = matchCursor{
+			Sort:  "created_at",
+			Desc:  true,
+			Value: last.SortValue,
+			URL:   last.Match.MatchUrl,
+		}.encode()
+		rows = rows[:limit]
+	}
+	for _, row := range rows {
+		resp.Matches = append(resp.Matches, matchToResponse(row.Match))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListPlayerFaceitMatchesHandler serves GET
+// /api/players/{steamID}/faceit-matches, the player's stats from the Faceit
+// Data API, newest first, next to their average K/D and ADR over as many of
+// their latest Faceit and Leetify matches to cross-check the two sources.
+//
+// Query parameters:
+//
+//	limit  number of matches from each source, 1-200 (default 50)
+func (s *Server) ListPlayerFaceitMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	steamID := r.PathValue("steamID")
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	_, err = s.db.GetPlayer(r.Context(), steamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "player not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to get player", err)
+		return
+	}
+
+	faceitRows, err := s.db.ListFaceitPlayerMatches(r.Context(), database.ListFaceitPlayerMatchesParams{
+		SteamID:  sql.NullString{String: steamID, Valid: true},
+		RowLimit: int64(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list faceit matches", err)
+		return
+	}
+	leetifyRows, err := s.db.ListPlayerMatchStats(r.Context(), database.ListPlayerMatchStatsParams{
+		SteamID:  sql.NullString{String: steamID, Valid: true},
+		RowLimit: int64(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to list player match stats", err)
+		return
+	}
+
+	resp := FaceitMatchesResponse{
+		SteamID: steamID,
+		Matches: []FaceitMatchResponse{},
+	}
+	var kds, adrs []sql.NullFloat64
+	for _, row := range faceitRows {
+		resp.Matches = append(resp.Matches, FaceitMatchResponse{
+			MatchID:      row.MatchID,
+			Map:          nullStringPtr(row.Map),
+			Won:          row.Won,
+			Kills:        nullInt64Ptr(row.Kills),
+			Deaths:       nullInt64Ptr(row.Deaths),
+			Assists:      nullInt64Ptr(row.Assists),
+			KD:           nullFloatPtr(row.Kd),
+			ADR:          nullFloatPtr(row.Adr),
+			HeadshotsPct: nullFloatPtr(row.HeadshotsPct),
+			FinishedAt:   nullTimePtr(row.FinishedAt),
+		})
+		kds = append(kds, row.Kd)
+		adrs = append(adrs, row.Adr)
+	}
+	resp.Faceit = sourceStats(len(faceitRows), kds, adrs)
+
+	kds, adrs = nil, nil
+	for _, row := range leetifyRows {
+		kds = append(kds, row.Kd)
+		adrs = append(adrs, row.Adr)
+	}
+	resp.Leetify = sourceStats(len(leetifyRows), kds, adrs)
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func sourceStats(matches int, kds, adrs []sql.NullFloat64) SourceStatsResponse {
+	return SourceStatsResponse{
+		Matches: matches,
+		KD:      meanPtr(kds),
+		ADR:     meanPtr(adrs),
+	}
+}
+
+// meanPtr averages the valid values, returning nil if there are none.
+func meanPtr(values []sql.NullFloat64) *float64 {
+	var valid []float64
+	for _, v := range values {
+		if v.Valid {
+			valid = append(valid, v.Float64)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	mean := aggregate(valid).Mean
+	return &mean
+}
+
+func playerToResponse(player database.Player) PlayerResponse {
+	return PlayerResponse{
+		SteamID:             player.SteamID,
+		Name:                player.Name,
+		Country:             player.Country,
+		FaceitURL:           player.FaceitUrl,
+		Avatar:              player.Avatar,
+		Region:              nullStringPtr(player.Region),
+		FaceitElo:           nullInt64Ptr(player.FaceitElo),
+		LeaderboardPosition: nullInt64Ptr(player.LeaderboardPosition),
+		CreatedAt:           player.CreatedAt,
+		UpdatedAt:           player.UpdatedAt,
+	}
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func insertTestPlayers(t *testing.T, s *Server) {
+	t.Helper()
+	for _, p := range []database.CreatePlayerParams{
+		{SteamID: "76561198000000001", Name: "ZywOo", Country: "fr"},
+		{SteamID: "76561198000000002", Name: "zont1x", Country: "ua"},
+		{SteamID: "76561198000000003", Name: "m0NESY", Country: "ru"},
+		{SteamID: "76561198000000004", Name: "z_z", Country: "fr"},
+	} {
+		if _, err := s.db.CreatePlayer(context.Background(), p); err != nil {
+			t.Fatalf("error inserting player. Err: %v", err)
+		}
+	}
+}
+
+func TestListPlayers(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+
+	tests := []struct {
+		query string
+		names []string
+	}{
+		{"", []string{"ZywOo", "m0NESY", "z_z", "zont1x"}},
+		{"name=z", []string{"ZywOo", "z_z", "zont1x"}},
+		{"name=z_", []string{"z_z"}},
+		{"country=FR", []string{"ZywOo", "z_z"}},
+		{"name=zo&country=ua", []string{"zont1x"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/players?"+tt.query, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected status OK; got %v", tt.query, rec.Code)
+		}
+		var resp ListPlayersResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("error decoding response. Err: %v", err)
+		}
+		var names []string
+		for _, p := range resp.Players {
+			names = append(names, p.Name)
+		}
+		if len(names) != len(tt.names) {
+			t.Errorf("%q: expected %v; got %v", tt.query, tt.names, names)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.names[i] {
+				t.Errorf("%q: expected %v; got %v", tt.query, tt.names, names)
+				break
+			}
+		}
+	}
+}
+
+func TestCreatePlayerRejectsInvalidSteamID(t *testing.T) {
+	s := newTestServer(t)
+	for _, steamID := range []string{"", "76561198", "7656119800000000x", "12345678901234567"} {
+		_, err := s.db.CreatePlayer(context.Background(), database.CreatePlayerParams{SteamID: steamID, Name: "bad"})
+		if err == nil {
+			t.Errorf("%q: expected CHECK constraint error", steamID)
+		}
+	}
+}
+
+func TestListPlayersPagination(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+
+	var names []string
+	cursor := ""
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/api/players?limit=3&cursor="+cursor, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp ListPlayersResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("error decoding response. Err: %v", err)
+		}
+		for _, p := range resp.Players {
+			names = append(names, p.Name)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if len(names) != 4 {
+		t.Errorf("expected 4 players across pages; got %v", names)
+	}
+}
+
+func TestGetPlayer(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/players/76561198000000003", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+	var player PlayerResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &player); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if player.Name != "m0NESY" || player.SteamID != "76561198000000003" {
+		t.Errorf("unexpected player %+v", player)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/players/76561198000000009", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404; got %v", rec.Code)
+	}
+}
+
+func TestGetPlayerElo(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for day, elo := range []int64{3100, 3150, 3120} {
+		err := s.db.CreatePlayerSnapshot(context.Background(), database.CreatePlayerSnapshotParams{
+			SteamID:             "76561198000000003",
+			CapturedAt:          start.AddDate(0, 0, day),
+			Region:              "EU",
+			FaceitElo:           elo,
+			SkillLevel:          10,
+			LeaderboardPosition: int64(3 - day),
+		})
+		if err != nil {
+			t.Fatalf("error inserting snapshot. Err: %v", err)
+		}
+	}
+
+	getElo := func(path string) (int, EloTimelineResponse) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp EloTimelineResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("error decoding response. Err: %v", err)
+			}
+		}
+		return rec.Code, resp
+	}
+
+	_, resp := getElo("/api/players/76561198000000003/elo")
+	if len(resp.Snapshots) != 3 || resp.Snapshots[0].FaceitElo != 3100 || resp.Snapshots[2].FaceitElo != 3120 {
+		t.Errorf("expected 3 snapshots oldest first; got %+v", resp.Snapshots)
+	}
+
+	_, resp = getElo("/api/players/76561198000000003/elo?from=2025-01-02T00:00:00Z")
+	if len(resp.Snapshots) != 2 || resp.Snapshots[0].FaceitElo != 3150 {
+		t.Errorf("expected 2 snapshots from Jan 2; got %+v", resp.Snapshots)
+	}
+
+	_, resp = getElo("/api/players/76561198000000001/elo")
+	if resp.Snapshots == nil || len(resp.Snapshots) != 0 {
+		t.Errorf("expected empty timeline; got %+v", resp.Snapshots)
+	}
+
+	if code, _ := getElo("/api/players/76561198000000009/elo"); code != http.StatusNotFound {
+		t.Errorf("expected status 404; got %v", code)
+	}
+}
+
+func TestListPlayerMatches(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+
+	var matches []database.CreateMatchParams
+	var links []database.CreatePlayerMatchParams
+	for i := range 5 {
+		url := fmt.Sprintf("https://leetify.com/app/match-details/%d", i)
+		matches = append(matches, database.CreateMatchParams{MatchUrl: url})
+		links = append(links, database.CreatePlayerMatchParams{SteamID: "76561198000000001", MatchUrl: url})
+	}
+	links = append(links, database.CreatePlayerMatchParams{SteamID: "76561198000000002", MatchUrl: matches[0].MatchUrl})
+	if err := BatchInsertMatches(context.Background(), s.dbConn, matches, nil, links); err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	var urls []string
+	cursor := ""
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/api/players/76561198000000001/matches?limit=2&cursor="+cursor, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", rec.Code)
+		}
+		var resp ListMatchesResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("error decoding response. Err: %v", err)
+		}
+		for _, m := range resp.Matches {
+			urls = append(urls, m.MatchURL)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if len(urls) != 5 || urls[0] != matches[4].MatchUrl {
+		t.Errorf("expected 5 matches newest first; got %v", urls)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/players/76561198000000002/matches", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var resp ListMatchesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if len(resp.Matches) != 1 {
+		t.Errorf("expected 1 match for second player; got %d", len(resp.Matches))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/players/76561198000000009/matches", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404; got %v", rec.Code)
+	}
+}
+
+func TestListPlayerFaceitMatches(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+	handler := s.RegisterRoutes()
+	steamID := sql.NullString{String: "76561198000000001", Valid: true}
+
+	var matches []database.CreateMatchParams
+	var players []database.CreateMatchPlayerParams
+	for i, kd := range []float64{1.2, 1.6} {
+		url := fmt.Sprintf("https://leetify.com/app/match-details/%d", i)
+		matches = append(matches, database.CreateMatchParams{MatchUrl: url})
+		players = append(players, database.CreateMatchPlayerParams{
+			MatchUrl:   url,
+			PlayerName: "ZywOo",
+			SteamID:    steamID,
+			Kd:         sql.NullFloat64{Float64: kd, Valid: true},
+		})
+	}
+	if err := BatchInsertMatches(context.Background(), s.dbConn, matches, players, nil); err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+	for i, kd := range []sql.NullFloat64{{Float64: 1.5, Valid: true}, {}, {Float64: 2.1, Valid: true}} {
+		err := s.db.CreateFaceitMatchPlayer(context.Background(), database.CreateFaceitMatchPlayerParams{
+			MatchID:    fmt.Sprintf("m%d", i),
+			PlayerID:   "faceit-1",
+			SteamID:    steamID,
+			Nickname:   "ZywOo",
+			Kd:         kd,
+			FinishedAt: sql.NullTime{Time: time.Date(2025, 6, 1+i, 0, 0, 0, 0, time.UTC), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("error inserting faceit match. Err: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/players/76561198000000001/faceit-matches?limit=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+	var resp FaceitMatchesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response. Err: %v", err)
+	}
+	if len(resp.Matches) != 2 || resp.Matches[0].MatchID != "m2" || resp.Matches[1].KD != nil {
+		t.Errorf("expected the 2 latest faceit matches; got %+v", resp.Matches)
+	}
+	if resp.Faceit.Matches != 2 || resp.Faceit.KD == nil || *resp.Faceit.KD != 2.1 || resp.Faceit.ADR != nil {
+		t.Errorf("unexpected faceit averages %+v", resp.Faceit)
+	}
+	if resp.Leetify.Matches != 2 || resp.Leetify.KD == nil || *resp.Leetify.KD != 1.4 {
+		t.Errorf("unexpected leetify averages %+v", resp.Leetify)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/players/76561198000000009/faceit-matches", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404; got %v", rec.Code)
+	}
+}
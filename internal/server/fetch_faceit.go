@@ -3,23 +3,37 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log"
+	"math/rand/v2"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
+// Players is a page of the CS2 rankings. Start and End are the offsets of the
+// page's first item and of the one after its last.
 type Players struct {
-	Items []struct {
-		PlayerID       string `json:"player_id"`
-		Nickname       string `json:"nickname"`
-		Country        string `json:"country"`
-		Position       int    `json:"position"`
-		FaceitElo      int    `json:"faceit_elo"`
-		GameSkillLevel int    `json:"game_skill_level"`
-	} `json:"items"`
-	Start int `json:"start"`
-	End   int `json:"end"`
+	Items []RankedPlayer `json:"items"`
+	Start int            `json:"start"`
+	End   int            `json:"end"`
+}
+
+// RankedPlayer is a player's entry in the CS2 rankings.
+type RankedPlayer struct {
+	PlayerID       string `json:"player_id"`
+	Nickname       string `json:"nickname"`
+	Country        string `json:"country"`
+	Position       int    `json:"position"`
+	FaceitElo      int    `json:"faceit_elo"`
+	GameSkillLevel int    `json:"game_skill_level"`
 }
 
 type PlayerDetails struct {
@@ -31,46 +45,400 @@ type PlayerDetails struct {
 	FaceitURL string `json:"faceit_url"`
 }
 
-const topPlayersURL string = "https://open.faceit.com/data/v4/rankings/games/cs2/regions/"
-const playerDetailsURL string = "https://open.faceit.com/data/v4/players/"
+// FaceitMatchHistory is a page of a player's match history, newest first.
+type FaceitMatchHistory struct {
+	Items []FaceitHistoryMatch `json:"items"`
+	Start int                  `json:"start"`
+	End   int                  `json:"end"`
+}
 
-func (s *Server) getTopPlayers(ctx context.Context, client *http.Client, region string, limit int, offset int) (*Players, error) {
-	url := getTopPlayersURL(region, offset, limit)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
+type FaceitHistoryMatch struct {
+	MatchID    string `json:"match_id"`
+	GameID     string `json:"game_id"`
+	GameMode   string `json:"game_mode"`
+	Status     string `json:"status"`      // e.g. "finished"
+	StartedAt  int64  `json:"started_at"`  // unix seconds
+	FinishedAt int64  `json:"finished_at"` // unix seconds
+	FaceitURL  string `json:"faceit_url"`
+}
+
+// FaceitMatchStats is the scoreboard of a match, with one entry in Rounds per
+// map played. Faceit reports every stat as a string, keyed by its display
+// name.
+type FaceitMatchStats struct {
+	Rounds []FaceitMapStats `json:"rounds"`
+}
+
+type FaceitMapStats struct {
+	MatchID    string            `json:"match_id"`
+	RoundStats map[string]string `json:"round_stats"` // e.g. "Map", "Score", "Winner"
+	Teams      []FaceitTeamStats `json:"teams"`
+}
+
+type FaceitTeamStats struct {
+	TeamID    string              `json:"team_id"`
+	TeamStats map[string]string   `json:"team_stats"` // e.g. "Final Score", "Team Win"
+	Players   []FaceitPlayerStats `json:"players"`
+}
+
+type FaceitPlayerStats struct {
+	PlayerID    string            `json:"player_id"`
+	Nickname    string            `json:"nickname"`
+	PlayerStats map[string]string `json:"player_stats"` // e.g. "Kills", "K/D Ratio", "ADR"
+}
+
+const defaultFaceitBaseURL string = "https://open.faceit.com/data/v4"
+
+// faceitRegions are the CS2 ranking regions Faceit publishes.
+var faceitRegions = []string{"EU", "NA", "SA", "OCE", "SEA"}
+
+// defaultFaceitRegion is scraped when FACEIT_REGIONS is unset.
+const defaultFaceitRegion = "EU"
+
+// parseRegion normalizes a region code, returning false if Faceit has no
+// ranking for it.
+func parseRegion(raw string) (string, bool) {
+	region := strings.ToUpper(strings.TrimSpace(raw))
+	return region, slices.Contains(faceitRegions, region)
+}
+
+// parseRegions parses a comma separated FACEIT_REGIONS value such as
+// "EU,NA". An empty value selects defaultFaceitRegion.
+func parseRegions(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []string{defaultFaceitRegion}, nil
+	}
+	var regions []string
+	for _, part := range strings.Split(raw, ",") {
+		region, ok := parseRegion(part)
+		if !ok {
+			return nil, fmt.Errorf("invalid FACEIT_REGIONS entry %q: must be one of %s", part, strings.Join(faceitRegions, ", "))
+		}
+		if !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	return regions, nil
+}
+
+// defaultFaceitRateLimit is the default request rate, in requests per
+// second, shared by every request the client makes. FACEIT_RATE_LIMIT
+// overrides it.
+const defaultFaceitRateLimit = 10
+
+// FaceitClient talks to the Faceit Data API. Point BaseURL at a stub or proxy
+// to run without hitting Faceit directly.
+//
+// Requests wait on Limiter, and those answered with 429 or a 5xx status are
+// retried up to MaxRetries times, after the Retry-After delay if the response
+// has one or an exponential backoff with jitter otherwise.
+type FaceitClient struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+	UserAgent  string
+
+	Limiter        *rate.Limiter
+	MaxRetries     int
+	RequestTimeout time.Duration // per attempt
+	MinBackoff     time.Duration // first retry delay, doubled on each retry
+	MaxBackoff     time.Duration // longest retry delay, Retry-After included
+}
+
+func NewFaceitClient(apiKey string) *FaceitClient {
+	return &FaceitClient{
+		BaseURL:        defaultFaceitBaseURL,
+		APIKey:         apiKey,
+		HTTPClient:     &http.Client{},
+		UserAgent:      "cs2-stat",
+		Limiter:        rate.NewLimiter(defaultFaceitRateLimit, defaultFaceitRateLimit),
+		MaxRetries:     5,
+		RequestTimeout: 15 * time.Second,
+		MinBackoff:     500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// parseRateLimit parses FACEIT_RATE_LIMIT, in requests per second. An empty
+// value selects defaultFaceitRateLimit.
+func parseRateLimit(raw string) (*rate.Limiter, error) {
+	if raw == "" {
+		return rate.NewLimiter(defaultFaceitRateLimit, defaultFaceitRateLimit), nil
+	}
+	perSecond, err := strconv.ParseFloat(raw, 64)
+	if err != nil || perSecond <= 0 {
+		return nil, fmt.Errorf("invalid FACEIT_RATE_LIMIT %q: must be a positive number of requests per second", raw)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), max(1, int(perSecond))), nil
+}
+
+// maxRankingsPageSize and maxHistoryPageSize are the most items Faceit
+// returns per rankings and match history request.
+const (
+	maxRankingsPageSize = 50
+	maxHistoryPageSize  = 100
+)
+
+// Rankings iterates over up to count players of the CS2 rankings for region,
+// starting at offset, requesting as many pages as that takes. It stops early
+// at the end of the leaderboard. A failed request is yielded as the last
+// element.
+func (c *FaceitClient) Rankings(ctx context.Context, region string, offset int, count int) iter.Seq2[RankedPlayer, error] {
+	return func(yield func(RankedPlayer, error) bool) {
+		for remaining := count; remaining > 0; {
+			limit := min(remaining, maxRankingsPageSize)
+			var page Players
+			if err := c.get(ctx, getTopPlayersPath(region, offset, limit), &page); err != nil {
+				yield(RankedPlayer{}, err)
+				return
+			}
+			for _, player := range page.Items[:min(len(page.Items), remaining)] {
+				if !yield(player, nil) {
+					return
+				}
+			}
+			// a short page is the last one
+			if len(page.Items) < limit {
+				return
+			}
+			remaining -= len(page.Items)
+			if page.End > offset {
+				offset = page.End
+			} else {
+				offset += len(page.Items)
+			}
+		}
+	}
+}
+
+// TopPlayers returns limit players of the CS2 rankings for region from offset,
+// or fewer at the end of the leaderboard.
+func (c *FaceitClient) TopPlayers(ctx context.Context, region string, offset int, limit int) (*Players, error) {
+	players := &Players{Start: offset, End: offset}
+	for player, err := range c.Rankings(ctx, region, offset, limit) {
+		if err != nil {
+			return nil, err
+		}
+		players.Items = append(players.Items, player)
+	}
+	players.End += len(players.Items)
+	return players, nil
+}
+
+func (c *FaceitClient) PlayerDetails(ctx context.Context, playerID string) (PlayerDetails, error) {
+	var player PlayerDetails
+	if err := c.get(ctx, getPlayerDetailsPath(playerID), &player); err != nil {
+		return PlayerDetails{}, err
+	}
+	return player, nil
+}
+
+// PlayerHistory returns a page of the player's CS2 match history. Faceit caps
+// limit at 100.
+func (c *FaceitClient) PlayerHistory(ctx context.Context, playerID string, offset int, limit int) (*FaceitMatchHistory, error) {
+	var history FaceitMatchHistory
+	if err := c.get(ctx, getPlayerHistoryPath(playerID, offset, limit), &history); err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "cs2-stat")
-	req.Header.Add("Authorization", "Bearer "+s.faceitApiKey)
+	return &history, nil
+}
 
-	res, err := client.Do(req)
-	if err != nil {
+func (c *FaceitClient) MatchStats(ctx context.Context, matchID string) (*FaceitMatchStats, error) {
+	var stats FaceitMatchStats
+	if err := c.get(ctx, getMatchStatsPath(matchID), &stats); err != nil {
 		return nil, err
 	}
+	return &stats, nil
+}
+
+// FaceitAPIError is a non-2xx response from the Faceit Data API. Code and
+// Message come from the first entry of the response's errors array, when
+// there is one.
+type FaceitAPIError struct {
+	Path       string
+	StatusCode int
+	Code       string // e.g. "err_nf0"
+	Message    string
+}
+
+func (e *FaceitAPIError) Error() string {
+	msg := fmt.Sprintf("faceit %s: %d %s", e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// isFaceitAuthError reports whether err is Faceit rejecting the API key, which
+// no retry or other request will fix.
+func isFaceitAuthError(err error) bool {
+	var apiErr *FaceitAPIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// isFaceitNotFound reports whether err is Faceit not knowing the requested
+// resource.
+func isFaceitNotFound(err error) bool {
+	var apiErr *FaceitAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// newFaceitAPIError reads the error body Faceit sends with a failed request,
+// {"errors": [{"message": ..., "code": ..., "http_status": ...}]}.
+func newFaceitAPIError(path string, statusCode int, body []byte) *FaceitAPIError {
+	apiErr := &FaceitAPIError{Path: path, StatusCode: statusCode}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) == nil && len(resp.Errors) > 0 {
+		apiErr.Code = resp.Errors[0].Code
+		apiErr.Message = resp.Errors[0].Message
+	}
+	return apiErr
+}
+
+// errRetryable marks an attempt that may succeed if repeated.
+type errRetryable struct {
+	err        error
+	retryAfter time.Duration // from the Retry-After header, 0 if absent
+}
+
+func (e *errRetryable) Error() string { return e.err.Error() }
+func (e *errRetryable) Unwrap() error { return e.err }
+
+func (c *FaceitClient) get(ctx context.Context, path string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, path, v)
+		var retryable *errRetryable
+		if err == nil || !errors.As(err, &retryable) {
+			return err
+		}
+		if attempt >= c.MaxRetries {
+			return retryable.err
+		}
+
+		delay := retryable.retryAfter
+		if delay == 0 {
+			delay = c.backoff(attempt)
+		}
+		// a Retry-After of hours would stall the whole scrape
+		if c.MaxBackoff > 0 && delay > c.MaxBackoff {
+			delay = c.MaxBackoff
+		}
+		log.Printf("Faceit request %s failed (%s), retrying in %s", path, err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt makes a single rate-limited request.
+func (c *FaceitClient) attempt(ctx context.Context, path string, v interface{}) error {
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	parent := ctx
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Add("Authorization", "Bearer "+c.APIKey)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		// network errors and timed out attempts are worth retrying, a
+		// cancelled job isn't
+		if parent.Err() != nil {
+			return err
+		}
+		return &errRetryable{err: err}
+	}
 	defer res.Body.Close()
 
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return &errRetryable{err: err}
 	}
 
-	var players Players
-	err = json.Unmarshal(data, &players)
-	if err != nil {
-		return nil, err
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := newFaceitAPIError(path, res.StatusCode, data)
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+			return &errRetryable{
+				err:        apiErr,
+				retryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+			}
+		}
+		return apiErr
 	}
 
-	return &players, nil
+	return json.Unmarshal(data, v)
+}
+
+// backoff returns the delay before retry attempt+1: MinBackoff doubled per
+// attempt, capped at MaxBackoff, with the upper half randomized so workers
+// that failed together don't retry together.
+func (c *FaceitClient) backoff(attempt int) time.Duration {
+	d := c.MinBackoff << attempt
+	if d <= 0 || d > c.MaxBackoff {
+		d = c.MaxBackoff
+	}
+	if d < 2 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
 }
 
-func (s *Server) getPlayerDetailsWithWorkers(ctx context.Context, client *http.Client, playerIDs []string) ([]PlayerDetails, error) {
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 when the header is absent or unparseable.
+func parseRetryAfter(raw string, now time.Time) time.Duration {
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(raw); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// playerResult is a worker's answer for one player ID.
+type playerResult struct {
+	playerID string
+	player   PlayerDetails
+	err      error
+}
+
+// getPlayerDetailsWithWorkers fetches the details of each player. Players that
+// still fail after the client's retries, or that Faceit doesn't know, are
+// logged, added to the job's errors and left out. A rejected API key fails the
+// whole batch.
+func (s *Server) getPlayerDetailsWithWorkers(ctx context.Context, playerIDs []string) ([]PlayerDetails, error) {
 	numWorkers := 5
 	jobs := make(chan string, len(playerIDs))
-	results := make(chan PlayerDetails, len(playerIDs))
+	results := make(chan playerResult, len(playerIDs))
 
 	// Start workers
 	for range numWorkers {
-		go worker(ctx, jobs, results, s, client)
+		go worker(ctx, jobs, results, s.faceit)
 	}
 
 	// Send all jobs
@@ -83,8 +451,20 @@ func (s *Server) getPlayerDetailsWithWorkers(ctx context.Context, client *http.C
 	var players []PlayerDetails
 	for range playerIDs {
 		select {
-		case player := <-results:
-			players = append(players, player)
+		case result := <-results:
+			switch {
+			case isFaceitAuthError(result.err):
+				return nil, result.err
+			case isFaceitNotFound(result.err):
+				log.Printf("Skipping player %s: not found on Faceit", result.playerID)
+				s.scrape.addError(fmt.Errorf("faceit player %s skipped: not found", result.playerID))
+				continue
+			case result.err != nil:
+				log.Printf("Error fetching player %s: %v", result.playerID, result.err)
+				s.scrape.addError(fmt.Errorf("faceit player %s: %w", result.playerID, result.err))
+				continue
+			}
+			players = append(players, result.player)
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		}
@@ -93,54 +473,43 @@ func (s *Server) getPlayerDetailsWithWorkers(ctx context.Context, client *http.C
 	return players, nil
 }
 
-func worker(ctx context.Context, jobs <-chan string, results chan<- PlayerDetails, s *Server, client *http.Client) {
+// worker fetches player details, sending exactly one result per job.
+func worker(ctx context.Context, jobs <-chan string, results chan<- playerResult, client *FaceitClient) {
 	for playerID := range jobs {
-		player, err := s.fetchSinglePlayer(ctx, playerID, client)
-		if err != nil {
-			log.Printf("Error fetching player %s: %v", playerID, err)
-			continue
-		}
-		results <- player
+		player, err := client.PlayerDetails(ctx, playerID)
+		results <- playerResult{playerID: playerID, player: player, err: err}
 	}
 }
 
-func (s *Server) fetchSinglePlayer(ctx context.Context, playerID string, client *http.Client) (PlayerDetails, error) {
-	url := getPlayerDetailsURL(playerID)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return PlayerDetails{}, err
+// validSteamID64 reports whether id is a 17 digit SteamID64 of an individual
+// account, e.g. 76561197960287930.
+func validSteamID64(id string) bool {
+	if len(id) != 17 || !strings.HasPrefix(id, "7656119") {
+		return false
 	}
-	req.Header.Set("User-Agent", "cs2-stat")
-	req.Header.Add("Authorization", "Bearer "+s.faceitApiKey)
-
-	res, err := client.Do(req)
-	if err != nil {
-		return PlayerDetails{}, err
-	}
-	defer res.Body.Close()
-
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
-		return PlayerDetails{}, err
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
+	return true
+}
 
-	var player PlayerDetails
-	err = json.Unmarshal(data, &player)
-	if err != nil {
-		return PlayerDetails{}, err
+func getTopPlayersPath(region string, offset int, limit int) string {
+	if limit > maxRankingsPageSize {
+		return fmt.Sprintf("/rankings/games/cs2/regions/%s?offset=%d&limit=%d", region, offset, maxRankingsPageSize)
 	}
+	return fmt.Sprintf("/rankings/games/cs2/regions/%s?offset=%d&limit=%d", region, offset, limit)
+}
 
-	return player, nil
+func getPlayerDetailsPath(playerID string) string {
+	return fmt.Sprintf("/players/%s", playerID)
 }
 
-func getTopPlayersURL(region string, offset int, limit int) string {
-	maxLimit := 50
-	if limit > maxLimit {
-		return fmt.Sprintf("%s%s?offset=%d&limit=%d", topPlayersURL, region, offset, maxLimit)
-	}
-	return fmt.Sprintf("%s%s?offset=%d&limit=%d", topPlayersURL, region, offset, limit)
+func getPlayerHistoryPath(playerID string, offset int, limit int) string {
+	return fmt.Sprintf("/players/%s/history?game=cs2&offset=%d&limit=%d", playerID, offset, min(limit, maxHistoryPageSize))
 }
 
-func getPlayerDetailsURL(playerID string) string {
-	return fmt.Sprintf("%s%s", playerDetailsURL, playerID)
+func getMatchStatsPath(matchID string) string {
+	return fmt.Sprintf("/matches/%s/stats", matchID)
 }
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseMatchHistory parses FACEIT_MATCH_HISTORY, the number of recent Faceit
+// matches pulled per player. An empty value or 0 turns the Faceit match stats
+// off.
+func parseMatchHistory(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > maxHistoryPageSize {
+		return 0, fmt.Errorf("invalid FACEIT_MATCH_HISTORY %q: must be a number of matches from 0 to %d", raw, maxHistoryPageSize)
+	}
+	return n, nil
+}
+
+// faceitMatchesTimeout bounds the Faceit match stats of one leaderboard
+// window, separately from the window's own timeout.
+const faceitMatchesTimeout = 10 * time.Minute
+
+// saveFaceitMatches pulls the last s.matchHistory CS2 matches of each player
+// from the Faceit Data API and saves every player's stats in them, so they
+// can be checked against the Leetify scoreboards. It returns the number of
+// matches saved, and stops with ctx's error once ctx is done.
+func (s *Server) saveFaceitMatches(ctx context.Context, players []PlayerDetails) (int, error) {
+	steamIDs := make(map[string]string, len(players))
+	for _, player := range players {
+		steamIDs[player.PlayerID] = player.SteamID64
+	}
+
+	// tracked players often share matches, fetch each one once
+	var matchIDs []string
+	finishedAt := make(map[string]time.Time)
+	for _, player := range players {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		history, err := s.faceit.PlayerHistory(ctx, player.PlayerID, 0, s.matchHistory)
+		if isFaceitAuthError(err) {
+			return 0, err
+		}
+		if err != nil {
+			log.Printf("Error fetching match history of %s: %v", player.Nickname, err)
+			s.scrape.addError(fmt.Errorf("faceit player %s history: %w", player.PlayerID, err))
+			continue
+		}
+		for _, match := range history.Items {
+			if _, seen := finishedAt[match.MatchID]; seen || !strings.EqualFold(match.Status, "finished") {
+				continue
+			}
+			matchIDs = append(matchIDs, match.MatchID)
+			finishedAt[match.MatchID] = time.Time{}
+			if match.FinishedAt > 0 {
+				finishedAt[match.MatchID] = time.Unix(match.FinishedAt, 0).UTC()
+			}
+		}
+	}
+
+	saved := 0
+	for _, matchID := range matchIDs {
+		if ctx.Err() != nil {
+			return saved, ctx.Err()
+		}
+		stats, err := s.faceit.MatchStats(ctx, matchID)
+		if isFaceitAuthError(err) {
+			return saved, err
+		}
+		if err != nil {
+			log.Printf("Error fetching stats of match %s: %v", matchID, err)
+			s.scrape.addError(fmt.Errorf("faceit match %s stats: %w", matchID, err))
+			continue
+		}
+
+		rows, err := faceitMatchPlayers(matchID, stats, finishedAt[matchID], steamIDs)
+		if err != nil {
+			log.Printf("Skipping faceit match %s: %v", matchID, err)
+			s.scrape.addError(fmt.Errorf("faceit match %s: %w", matchID, err))
+			continue
+		}
+		if err := insertFaceitMatch(ctx, s.dbConn, rows); err != nil {
+			log.Printf("Error saving faceit match %s: %v", matchID, err)
+			s.scrape.addError(fmt.Errorf("faceit match %s: failed to save: %w", matchID, err))
+			continue
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// insertFaceitMatch upserts the player lines of one match in a single
+// transaction, so a failed match isn't left half written.
+func insertFaceitMatch(ctx context.Context, db *sql.DB, rows []database.CreateFaceitMatchPlayerParams) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	qtx := database.New(tx)
+	for _, row := range rows {
+		if err := qtx.CreateFaceitMatchPlayer(ctx, row); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// faceitMatchPlayers maps the scoreboard of a match to faceit_match_players
+// rows, filling in the steam IDs of the players in steamIDs. Matchmaking
+// matches are a single map, so only the first one is read.
+func faceitMatchPlayers(matchID string, stats *FaceitMatchStats, finishedAt time.Time, steamIDs map[string]string) ([]database.CreateFaceitMatchPlayerParams, error) {
+	if len(stats.Rounds) == 0 {
+		return nil, fmt.Errorf("no stats")
+	}
+	round := stats.Rounds[0]
+	if len(round.Teams) != 2 {
+		return nil, fmt.Errorf("expected 2 teams; got %d", len(round.Teams))
+	}
+
+	var rows []database.CreateFaceitMatchPlayerParams
+	for team, teamStats := range round.Teams {
+		won := teamStats.TeamStats["Team Win"] == "1"
+		for _, player := range teamStats.Players {
+			row := database.CreateFaceitMatchPlayerParams{
+				MatchID:      matchID,
+				PlayerID:     player.PlayerID,
+				Nickname:     player.Nickname,
+				Team:         int64(team),
+				Won:          won,
+				Map:          sql.NullString{String: round.RoundStats["Map"], Valid: round.RoundStats["Map"] != ""},
+				Kills:        faceitCount(player.PlayerStats["Kills"]),
+				Deaths:       faceitCount(player.PlayerStats["Deaths"]),
+				Assists:      faceitCount(player.PlayerStats["Assists"]),
+				Kd:           faceitStat(player.PlayerStats["K/D Ratio"]),
+				Adr:          faceitStat(player.PlayerStats["ADR"]),
+				HeadshotsPct: faceitStat(player.PlayerStats["Headshots %"]),
+				FinishedAt:   sql.NullTime{Time: finishedAt, Valid: !finishedAt.IsZero()},
+			}
+			if steamID := steamIDs[player.PlayerID]; steamID != "" {
+				row.SteamID = sql.NullString{String: steamID, Valid: true}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// faceitStat reads a Faceit stat value, mapping a missing or malformed one to
+// NULL.
+func faceitStat(raw string) sql.NullFloat64 {
+	stat, err := parseStat(raw)
+	if err != nil {
+		return sql.NullFloat64{}
+	}
+	return stat.nullFloat()
+}
+
+func faceitCount(raw string) sql.NullInt64 {
+	stat := faceitStat(raw)
+	return sql.NullInt64{Int64: int64(stat.Float64), Valid: stat.Valid}
+}
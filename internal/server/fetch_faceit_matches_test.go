@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newFaceitMatchStub serves the match history and match stats endpoints.
+// history and stats are keyed by player and match ID, and statsRequests counts
+// the stats requests for the matches it has a counter for.
+func newFaceitMatchStub(t *testing.T, history map[string][]FaceitHistoryMatch, stats map[string]FaceitMatchStats, statsRequests map[string]*atomic.Int32) *FaceitClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /players/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("game") != "cs2" {
+			t.Errorf("expected cs2 history; got %q", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(FaceitMatchHistory{Items: history[r.PathValue("id")]})
+	})
+	mux.HandleFunc("GET /matches/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if n, ok := statsRequests[id]; ok {
+			n.Add(1)
+		}
+		match, ok := stats[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"The resource was not found.","code":"err_nf0","http_status":404}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(match)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewFaceitClient("test-key")
+	client.BaseURL = server.URL
+	return client
+}
+
+func faceitStatsPlayer(id, kills, kd, adr string) FaceitPlayerStats {
+	return FaceitPlayerStats{
+		PlayerID:    id,
+		Nickname:    id,
+		PlayerStats: map[string]string{"Kills": kills, "Deaths": "15", "Assists": "3", "K/D Ratio": kd, "ADR": adr, "Headshots %": "48"},
+	}
+}
+
+func TestSaveFaceitMatches(t *testing.T) {
+	s := newTestServer(t)
+	s.matchHistory = 20
+	requests := map[string]*atomic.Int32{"m1": {}}
+	s.faceit = newFaceitMatchStub(t,
+		map[string][]FaceitHistoryMatch{
+			"faceit-1": {
+				{MatchID: "m1", Status: "finished", FinishedAt: 1750000000},
+				{MatchID: "m2", Status: "ongoing"},
+				{MatchID: "m3", Status: "finished"},
+			},
+			"faceit-2": {{MatchID: "m1", Status: "FINISHED", FinishedAt: 1750000000}},
+		},
+		map[string]FaceitMatchStats{
+			"m1": {Rounds: []FaceitMapStats{{
+				RoundStats: map[string]string{"Map": "de_mirage", "Score": "13 / 9"},
+				Teams: []FaceitTeamStats{
+					{TeamStats: map[string]string{"Team Win": "0"}, Players: []FaceitPlayerStats{faceitStatsPlayer("faceit-2", "12", "0.8", "70.5")}},
+					{TeamStats: map[string]string{"Team Win": "1"}, Players: []FaceitPlayerStats{faceitStatsPlayer("faceit-1", "25", "1.67", "101.2"), faceitStatsPlayer("faceit-9", "18", "n/a", "")}},
+				},
+			}}},
+		},
+		requests,
+	)
+
+	saved, err := s.saveFaceitMatches(context.Background(), []PlayerDetails{
+		{PlayerID: "faceit-1", Nickname: "one", SteamID64: "76561198000000001"},
+		{PlayerID: "faceit-2", Nickname: "two", SteamID64: "76561198000000002"},
+	})
+	if err != nil {
+		t.Fatalf("error saving faceit matches. Err: %v", err)
+	}
+	if saved != 1 || requests["m1"].Load() != 1 {
+		t.Errorf("expected the shared match fetched and saved once; got %d saved, %d requests", saved, requests["m1"].Load())
+	}
+	if errs := s.scrape.snapshot().Errors; len(errs) != 1 {
+		t.Errorf("expected the missing m3 stats reported; got %v", errs)
+	}
+
+	var rows, tracked int
+	if err := s.dbConn.QueryRow(`SELECT COUNT(*), COUNT(steam_id) FROM faceit_match_players WHERE match_id = 'm1'`).Scan(&rows, &tracked); err != nil {
+		t.Fatalf("error counting rows. Err: %v", err)
+	}
+	if rows != 3 || tracked != 2 {
+		t.Errorf("expected 3 players, 2 with steam IDs; got %d, %d", rows, tracked)
+	}
+
+	one, err := s.db.ListFaceitPlayerMatches(context.Background(), database.ListFaceitPlayerMatchesParams{
+		SteamID:  sql.NullString{String: "76561198000000001", Valid: true},
+		RowLimit: 10,
+	})
+	if err != nil {
+		t.Fatalf("error listing faceit matches. Err: %v", err)
+	}
+	if len(one) != 1 || !one[0].Won || one[0].Kills.Int64 != 25 || one[0].Kd.Float64 != 1.67 || one[0].Map.String != "de_mirage" || one[0].FinishedAt.Time.Unix() != 1750000000 {
+		t.Errorf("unexpected faceit stats %+v", one)
+	}
+
+	var kdValid bool
+	if err := s.dbConn.QueryRow(`SELECT kd IS NOT NULL FROM faceit_match_players WHERE player_id = 'faceit-9'`).Scan(&kdValid); err != nil {
+		t.Fatalf("error reading row. Err: %v", err)
+	}
+	if kdValid {
+		t.Error("expected a placeholder K/D stored as NULL")
+	}
+}
+
+func TestSaveFaceitMatchesSkipsFailedInserts(t *testing.T) {
+	s := newTestServer(t)
+	s.matchHistory = 20
+	stats := func(players ...string) FaceitMatchStats {
+		var team []FaceitPlayerStats
+		for _, id := range players {
+			team = append(team, faceitStatsPlayer(id, "20", "1.1", "80"))
+		}
+		return FaceitMatchStats{Rounds: []FaceitMapStats{{Teams: []FaceitTeamStats{{Players: team}, {}}}}}
+	}
+	s.faceit = newFaceitMatchStub(t,
+		map[string][]FaceitHistoryMatch{"faceit-1": {{MatchID: "bad", Status: "finished"}, {MatchID: "good", Status: "finished"}}},
+		map[string]FaceitMatchStats{"bad": stats("faceit-1", "faceit-2"), "good": stats("faceit-1")},
+		nil,
+	)
+	// fail the second player line of the bad match
+	_, err := s.dbConn.Exec(`CREATE TRIGGER fail_insert BEFORE INSERT ON faceit_match_players
+		WHEN NEW.match_id = 'bad' AND NEW.player_id = 'faceit-2'
+		BEGIN SELECT RAISE(ABORT, 'insert failed'); END`)
+	if err != nil {
+		t.Fatalf("error creating trigger. Err: %v", err)
+	}
+
+	saved, err := s.saveFaceitMatches(context.Background(), []PlayerDetails{{PlayerID: "faceit-1", SteamID64: "76561198000000001"}})
+	if err != nil || saved != 1 {
+		t.Fatalf("expected the good match saved despite the failed one; got %d saved, %v", saved, err)
+	}
+	if errs := s.scrape.snapshot().Errors; len(errs) != 1 || !strings.Contains(errs[0], "faceit match bad") {
+		t.Errorf("expected the failed insert reported; got %v", errs)
+	}
+
+	var bad int
+	if err := s.dbConn.QueryRow(`SELECT COUNT(*) FROM faceit_match_players WHERE match_id = 'bad'`).Scan(&bad); err != nil {
+		t.Fatalf("error counting rows. Err: %v", err)
+	}
+	if bad != 0 {
+		t.Errorf("expected the failed match rolled back; got %d rows", bad)
+	}
+}
+
+func TestSaveFaceitMatchesStopsWhenDone(t *testing.T) {
+	s := newTestServer(t)
+	s.matchHistory = 20
+	requests := map[string]*atomic.Int32{"m1": {}, "m2": {}}
+	s.faceit = newFaceitMatchStub(t,
+		map[string][]FaceitHistoryMatch{"faceit-1": {{MatchID: "m1", Status: "finished"}, {MatchID: "m2", Status: "finished"}}},
+		nil,
+		requests,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	saved, err := s.saveFaceitMatches(ctx, []PlayerDetails{{PlayerID: "faceit-1", SteamID64: "76561198000000001"}})
+	if !errors.Is(err, context.Canceled) || saved != 0 {
+		t.Errorf("expected to stop with the context error; got %d saved, %v", saved, err)
+	}
+	if n := requests["m1"].Load() + requests["m2"].Load(); n != 0 {
+		t.Errorf("expected no stats requests after the context is done; got %d", n)
+	}
+	if errs := s.scrape.snapshot().Errors; len(errs) != 0 {
+		t.Errorf("expected no per-match errors once the context is done; got %v", errs)
+	}
+}
+
+func TestParseMatchHistory(t *testing.T) {
+	if n, err := parseMatchHistory(""); err != nil || n != 0 {
+		t.Errorf("expected Faceit match stats off by default; got %d, %v", n, err)
+	}
+	if n, err := parseMatchHistory("20"); err != nil || n != 20 {
+		t.Errorf("expected 20; got %d, %v", n, err)
+	}
+	for _, raw := range []string{"-1", "101", "all"} {
+		if _, err := parseMatchHistory(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newFaceitStub serves the rankings and player details endpoints for players,
+// keyed by Faceit player ID. The rankings list players by ID and page like
+// Faceit's, at most 50 at a time.
+func newFaceitStub(t *testing.T, players map[string]PlayerDetails) *FaceitClient {
+	t.Helper()
+	ids := slices.Sorted(maps.Keys(players))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rankings/games/cs2/regions/{region}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("expected API key in Authorization header; got %q", r.Header.Get("Authorization"))
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit > 50 {
+			t.Errorf("expected limit of at most 50; got %d", limit)
+		}
+		resp := Players{Start: offset, End: offset + limit}
+		for i := offset; i < min(offset+limit, len(ids)); i++ {
+			p := players[ids[i]]
+			resp.Items = append(resp.Items, RankedPlayer{PlayerID: ids[i], Nickname: p.Nickname, Country: p.Country, Position: i + 1})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /players/{id}", func(w http.ResponseWriter, r *http.Request) {
+		player, ok := players[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"The resource was not found.","code":"err_nf0","http_status":404}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(player)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := NewFaceitClient("test-key")
+	client.BaseURL = server.URL
+	client.Limiter = rate.NewLimiter(rate.Inf, 1)
+	return client
+}
+
+// stubLeaderboard returns n players without steam IDs for newFaceitStub, so a
+// job pages through them without scraping anything.
+func stubLeaderboard(n int) map[string]PlayerDetails {
+	players := make(map[string]PlayerDetails, n)
+	for i := range n {
+		id := fmt.Sprintf("faceit-%04d", i+1)
+		players[id] = PlayerDetails{PlayerID: id, Nickname: id}
+	}
+	return players
+}
+
+func TestFaceitClient(t *testing.T) {
+	client := newFaceitStub(t, map[string]PlayerDetails{
+		"faceit-1": {PlayerID: "faceit-1", Nickname: "donk", SteamID64: "76561198386265483"},
+	})
+
+	players, err := client.TopPlayers(context.Background(), "EU", 0, 50)
+	if err != nil {
+		t.Fatalf("error getting top players. Err: %v", err)
+	}
+	if len(players.Items) != 1 || players.Items[0].PlayerID != "faceit-1" {
+		t.Errorf("unexpected rankings %+v", players)
+	}
+
+	player, err := client.PlayerDetails(context.Background(), "faceit-1")
+	if err != nil {
+		t.Fatalf("error getting player details. Err: %v", err)
+	}
+	if player.SteamID64 != "76561198386265483" {
+		t.Errorf("unexpected player %+v", player)
+	}
+}
+
+func TestRankingsPages(t *testing.T) {
+	client := newFaceitStub(t, stubLeaderboard(120))
+
+	players, err := client.TopPlayers(context.Background(), "EU", 0, 100)
+	if err != nil {
+		t.Fatalf("error getting top players. Err: %v", err)
+	}
+	if len(players.Items) != 100 || players.Items[99].Position != 100 || players.Start != 0 || players.End != 100 {
+		t.Errorf("expected positions 1-100 over two pages; got %d players ending at %d", len(players.Items), players.End)
+	}
+
+	players, err = client.TopPlayers(context.Background(), "EU", 30, 45)
+	if err != nil {
+		t.Fatalf("error getting top players. Err: %v", err)
+	}
+	if len(players.Items) != 45 || players.Items[0].Position != 31 || players.Items[44].Position != 75 {
+		t.Errorf("expected positions 31-75; got %+v", players)
+	}
+
+	var positions []int
+	for player, err := range client.Rankings(context.Background(), "EU", 90, 100) {
+		if err != nil {
+			t.Fatalf("error iterating rankings. Err: %v", err)
+		}
+		positions = append(positions, player.Position)
+	}
+	if len(positions) != 30 || positions[0] != 91 || positions[29] != 120 {
+		t.Errorf("expected positions 91-120 up to the end of the leaderboard; got %v", positions)
+	}
+
+	var n int
+	for range client.Rankings(context.Background(), "EU", 0, 200) {
+		if n++; n == 60 {
+			break
+		}
+	}
+	if n != 60 {
+		t.Errorf("expected to stop after 60 players; got %d", n)
+	}
+}
+
+func TestGetTopPlayersPathClampsLimit(t *testing.T) {
+	path := getTopPlayersPath("EU", 100, 200)
+	if !strings.HasSuffix(path, "/EU?offset=100&limit=50") {
+		t.Errorf("expected limit clamped to 50; got %s", path)
+	}
+}
+
+func TestParseRegions(t *testing.T) {
+	regions, err := parseRegions("")
+	if err != nil || len(regions) != 1 || regions[0] != "EU" {
+		t.Errorf("expected default EU; got %v, %v", regions, err)
+	}
+	regions, err = parseRegions("eu, NA,sea,EU")
+	if err != nil || strings.Join(regions, ",") != "EU,NA,SEA" {
+		t.Errorf("expected EU,NA,SEA; got %v, %v", regions, err)
+	}
+	if _, err := parseRegions("EU,ASIA"); err == nil {
+		t.Error("expected error for unknown region")
+	}
+}
+
+func TestValidSteamID64(t *testing.T) {
+	for id, want := range map[string]bool{
+		"76561197960287930":  true,
+		"":                   false,
+		"7656119796028793":   false,
+		"765611979602879300": false,
+		"7656119796028793x":  false,
+		"12345678901234567":  false,
+	} {
+		if got := validSteamID64(id); got != want {
+			t.Errorf("validSteamID64(%q) = %v; want %v", id, got, want)
+		}
+	}
+}
+
+// newFlakyFaceit serves player details after failing the first failures
+// requests with status.
+func newFlakyFaceit(t *testing.T, failures int, status int, retryAfter string) (*FaceitClient, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(PlayerDetails{PlayerID: "faceit-1", Nickname: "donk"})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewFaceitClient("test-key")
+	client.BaseURL = server.URL
+	client.Limiter = rate.NewLimiter(rate.Inf, 1)
+	client.MinBackoff = time.Millisecond
+	client.MaxBackoff = 5 * time.Millisecond
+	return client, &calls
+}
+
+func TestFaceitClientRetries(t *testing.T) {
+	client, calls := newFlakyFaceit(t, 2, http.StatusServiceUnavailable, "")
+	player, err := client.PlayerDetails(context.Background(), "faceit-1")
+	if err != nil || player.Nickname != "donk" || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt; got %+v, %v after %d calls", player, err, calls.Load())
+	}
+
+	client, calls = newFlakyFaceit(t, 10, http.StatusTooManyRequests, "")
+	client.MaxRetries = 2
+	if _, err := client.PlayerDetails(context.Background(), "faceit-1"); err == nil || calls.Load() != 3 {
+		t.Errorf("expected error after 2 retries; got %v after %d calls", err, calls.Load())
+	}
+
+	client, calls = newFlakyFaceit(t, 1, http.StatusTooManyRequests, "1")
+	client.MaxBackoff = 5 * time.Second
+	start := time.Now()
+	if _, err := client.PlayerDetails(context.Background(), "faceit-1"); err != nil {
+		t.Fatalf("error getting player details. Err: %v", err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("expected to wait for Retry-After; waited %s", waited)
+	}
+
+	client, calls = newFlakyFaceit(t, 1, http.StatusTooManyRequests, "3600")
+	start = time.Now()
+	if _, err := client.PlayerDetails(context.Background(), "faceit-1"); err != nil {
+		t.Fatalf("error getting player details. Err: %v", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("expected Retry-After capped at MaxBackoff; waited %s", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 7, 14, 20, 0, 0, 0, time.UTC)
+	for raw, want := range map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"soon":                          0,
+		"Mon, 14 Jul 2025 20:00:30 GMT": 30 * time.Second,
+		"Mon, 14 Jul 2025 19:00:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(raw, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s; want %s", raw, got, want)
+		}
+	}
+}
+
+func TestBackoffIsCappedWithJitter(t *testing.T) {
+	client := NewFaceitClient("test-key")
+	for attempt := range 10 {
+		d := client.backoff(attempt)
+		ceiling := min(client.MinBackoff<<attempt, client.MaxBackoff)
+		if d < ceiling/2 || d >= ceiling {
+			t.Errorf("backoff(%d) = %s; want in [%s, %s)", attempt, d, ceiling/2, ceiling)
+		}
+	}
+}
+
+func TestGetPlayerDetailsSkipsFailedPlayers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /players/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(PlayerDetails{PlayerID: r.PathValue("id")})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	s := newTestServer(t)
+	s.faceit = NewFaceitClient("test-key")
+	s.faceit.BaseURL = server.URL
+	s.faceit.MaxRetries = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	players, err := s.getPlayerDetailsWithWorkers(ctx, []string{"a", "broken", "b"})
+	if err != nil {
+		t.Fatalf("expected the failed player to be skipped, not to stall the batch. Err: %v", err)
+	}
+	if len(players) != 2 || len(s.scrape.snapshot().Errors) != 1 {
+		t.Errorf("expected 2 players and 1 error; got %+v, %v", players, s.scrape.snapshot().Errors)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	limiter, err := parseRateLimit("")
+	if err != nil || limiter.Limit() != defaultFaceitRateLimit {
+		t.Errorf("expected default rate limit; got %v, %v", limiter, err)
+	}
+	limiter, err = parseRateLimit("0.5")
+	if err != nil || limiter.Limit() != 0.5 || limiter.Burst() != 1 {
+		t.Errorf("expected 0.5 requests per second with burst 1; got %v, %v", limiter, err)
+	}
+	for _, raw := range []string{"0", "-1", "fast"} {
+		if _, err := parseRateLimit(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestFaceitAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"message":"Invalid API key","code":"err_u0","http_status":401}]}`))
+	}))
+	t.Cleanup(server.Close)
+	client := NewFaceitClient("expired-key")
+	client.BaseURL = server.URL
+
+	_, err := client.TopPlayers(context.Background(), "EU", 0, 50)
+	var apiErr *FaceitAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "err_u0" || apiErr.Message != "Invalid API key" {
+		t.Fatalf("expected a 401 FaceitAPIError; got %v", err)
+	}
+	if !isFaceitAuthError(err) || isFaceitNotFound(err) {
+		t.Errorf("expected %v to be an auth error", err)
+	}
+
+	// a rejected key stops the job instead of running every window
+	s := newTestServer(t)
+	s.faceit = client
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.FetchAndScrapeJob(ctx); !isFaceitAuthError(err) {
+		t.Errorf("expected job to fail fast on auth error; got %v", err)
+	}
+}
+
+func TestGetPlayerDetailsSkipsUnknownPlayers(t *testing.T) {
+	s := newTestServer(t)
+	s.faceit = newFaceitStub(t, map[string]PlayerDetails{
+		"faceit-1": {PlayerID: "faceit-1", Nickname: "donk"},
+	})
+
+	players, err := s.getPlayerDetailsWithWorkers(context.Background(), []string{"faceit-1", "deleted"})
+	if err != nil {
+		t.Fatalf("error getting player details. Err: %v", err)
+	}
+	errs := s.scrape.snapshot().Errors
+	if len(players) != 1 || len(errs) != 1 || !strings.Contains(errs[0], "deleted skipped: not found") {
+		t.Errorf("expected deleted skipped with a reason; got %+v, %v", players, errs)
+	}
+}
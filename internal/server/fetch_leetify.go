@@ -2,60 +2,218 @@ package server
 
 import (
 	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"fmt"
 	"log"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
 
+// PlayerStats is a player's scoreboard row. Each Stat field is filled from
+// the column given for it in metrics.
 type PlayerStats struct {
 	Name                string
-	LeetifyRating       string
-	PersonalPerformance string
-	HLTVRating          string
-	KD                  string
-	ADR                 string
-	Aim                 string
-	Utility             string
+	SteamID             string
+	LeetifyRating       Stat
+	PersonalPerformance Stat
+	HLTVRating          Stat
+	KD                  Stat
+	ADR                 Stat
+	Aim                 Stat
+	Utility             Stat
 }
 
+// Outcome is how a match ended for one team.
+type Outcome string
+
+const (
+	OutcomeWin  Outcome = "win"
+	OutcomeLoss Outcome = "loss"
+	OutcomeTie  Outcome = "tie"
+)
+
 type Team struct {
 	Players []PlayerStats
-	Won     bool
+	Outcome Outcome
 }
 
 type Match struct {
-	Teams    [2]Team // [0]: winner, [1]: loser
-	MatchURL string
+	Teams      [2]Team // [0]: winner, [1]: loser; scoreboard order for a tie
+	MatchURL   string
+	Map        string    // e.g. "de_mirage"
+	Rounds     [2]int    // rounds won by Teams[0] and Teams[1]; zero when unknown
+	PlayedAt   time.Time // zero when unknown
+	DataSource string    // see parseDataSource
 }
 
+// MatchAverageStats holds the per-team stats of a match, Teams[0] being the
+// winner or, for a tie, the first team on the scoreboard.
 type MatchAverageStats struct {
-	MatchURL                   string
-	WinAvgLeetifyRating        float64
-	WinAvgPersonalPerformance  float64
-	WinAvgHTLVRating           float64
-	WinAvgKD                   float64
-	WinAvgAim                  float64
-	WinAvgUtility              float64
-	LossAvgLeetifyRating       float64
-	LossAvgPersonalPerformance float64
-	LossAvgHTLVRating          float64
-	LossAvgKD                  float64
-	LossAvgAim                 float64
-	LossAvgUtility             float64
+	MatchURL string
+	Tie      bool
+	Teams    [2]TeamStats
 }
 
 const leetifyUserURL string = "https://leetify.com/app/profile/"
 
 // ScrapedMatchData represents the raw data scraped from a match page
 type ScrapedMatchData struct {
-	Data [][]string
-	URL  string
+	Result string        `json:"result"` // text of div.phrase, e.g. "TIE"
+	Teams  []ScrapedTeam `json:"teams"`  // scoreboard sections in page order
+	URL    string        `json:"url"`
+	ScrapedMatchDetails
+}
+
+// ScrapedTeam is one team's section of the scoreboard.
+type ScrapedTeam struct {
+	Name     string     `json:"name"`
+	Score    string     `json:"score"` // rounds won as shown in the team header, "" when missing
+	Rows     [][]string `json:"rows"`
+	SteamIDs []string   `json:"steam_ids"` // SteamID64 of each row in Rows, "" when the row has no profile link
+}
+
+// MatchParseError is a match page whose scoreboard could not be read.
+type MatchParseError struct {
+	MatchURL string
+	Reason   string
+}
+
+func (e *MatchParseError) Error() string {
+	return fmt.Sprintf("match %s: %s", e.MatchURL, e.Reason)
+}
+
+// StatParseError is a scoreboard cell that couldn't be read as a number.
+// Column is the match_players column the cell feeds.
+type StatParseError struct {
+	MatchURL string
+	Player   string
+	Column   string
+	Value    string
+	Err      error
+}
+
+func (e *StatParseError) Error() string {
+	return fmt.Sprintf("match %s: player %s: %s %q: %s", e.MatchURL, e.Player, e.Column, e.Value, e.Err)
+}
+
+func (e *StatParseError) Unwrap() error {
+	return e.Err
+}
+
+// ScrapedMatchDetails is the raw match header text; any field may be empty.
+type ScrapedMatchDetails struct {
+	Map        string `json:"map"`         // e.g. "Mirage"
+	Score      string `json:"score"`       // e.g. "13 : 9"
+	PlayedAt   string `json:"played_at"`   // datetime attribute or text of the match date
+	DataSource string `json:"data_source"` // e.g. "FACEIT", "Premier"
+}
+
+// ChromedpScraper is the MatchLinkSource and MatchStatsSource that drives a
+// headless Chrome through Leetify. Start must be called before scraping and
+// Close once the job is done.
+//
+// Profiles are scrolled, or their "load more" button clicked, until they show
+// enough matches for MatchLinkLimits or stop growing, at most MaxLoadMore
+// times.
+type ChromedpScraper struct {
+	MatchLinkLimits
+	MaxLoadMore int
+
+	browserCtx context.Context
+	cancel     context.CancelFunc
+}
+
+func NewChromedpScraper() *ChromedpScraper {
+	return &ChromedpScraper{
+		MatchLinkLimits: MatchLinkLimits{Depth: defaultMatchDepth},
+		MaxLoadMore:     defaultMaxLoadMore,
+	}
+}
+
+func (c *ChromedpScraper) Start() error {
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.Flag("headless", true),
+			chromedp.Flag("disable-gpu", true),
+			chromedp.Flag("no-sandbox", true),
+			chromedp.Flag("disable-dev-shm-usage", true),
+			chromedp.Flag("disable-web-security", true),
+			chromedp.Flag("disable-features", "VizDisplayCompositor"),
+			chromedp.Flag("disable-background-timer-throttling", true),
+			chromedp.Flag("disable-backgrounding-occluded-windows", true),
+			chromedp.Flag("disable-renderer-backgrounding", true),
+			chromedp.Flag("disable-ipc-flooding-protection", true),
+		)...,
+	)
+
+	scrapeCtx, scrapeCancel := chromedp.NewContext(
+		allocCtx,
+		chromedp.WithLogf(log.Printf),
+	)
+
+	c.browserCtx = scrapeCtx
+	c.cancel = func() {
+		scrapeCancel()
+		allocCancel()
+	}
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-scrapeCtx.Done():
+				return
+			case <-ticker.C:
+				var version string
+				if err := chromedp.Run(scrapeCtx, chromedp.Evaluate(`navigator.userAgent`, &version)); err != nil {
+					log.Printf("Heartbeat failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *ChromedpScraper) Close() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *ChromedpScraper) MatchLinks(ctx context.Context, profileURLs []string) ([]MatchLink, error) {
+	runCtx, cancel := c.runContext(ctx)
+	defer cancel()
+	return scrapeMatchLinksWithWorkers(runCtx, profileURLs, c.MatchLinkLimits, c.MaxLoadMore)
+}
+
+func (c *ChromedpScraper) MatchStats(ctx context.Context, matchLinks []string) ([]Match, []error, error) {
+	runCtx, cancel := c.runContext(ctx)
+	defer cancel()
+	return scrapeMatchesWithWorkers(runCtx, matchLinks)
 }
 
-func (s *Server) scrapeMatchesWithWorkers(parentCtx context.Context, matchLinks []string) ([]Match, error) {
+// runContext derives tabs from the browser while still stopping when the
+// caller's ctx is cancelled.
+func (c *ChromedpScraper) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(c.browserCtx)
+	stop := context.AfterFunc(ctx, cancel)
+	return runCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+func scrapeMatchesWithWorkers(parentCtx context.Context, matchLinks []string) ([]Match, []error, error) {
 	numWorkers := 5
 	jobs := make(chan string, len(matchLinks))
 	results := make(chan ScrapedMatchData, len(matchLinks)*10)
@@ -111,57 +269,186 @@ resultsLoop:
 		}
 	}
 
+	matches, parseErrs := parseScrapedMatches(allMatches)
+	log.Printf("Successfully processed %d matches out of %d scraped", len(matches), len(allMatches))
+	return matches, parseErrs, nil
+}
+
+// parseScrapedMatches turns raw scoreboard tables into matches. Pages whose
+// scoreboard doesn't read as two teams of five with a known result are
+// returned as MatchParseErrors instead.
+func parseScrapedMatches(scraped []ScrapedMatchData) ([]Match, []error) {
 	var matches []Match
-	for _, match := range allMatches {
-		// leetify scrape returns some empty arrays
-		var validMatches [][]string
-		for _, player := range match.Data {
-			if len(player) == 0 {
-				continue
-			}
-			validMatches = append(validMatches, player)
+	var parseErrs []error
+	for _, match := range scraped {
+		teams, rounds, statErrs, err := parseScoreboard(match)
+		if err != nil {
+			log.Printf("Skipping match %s: %s", match.URL, err)
+			parseErrs = append(parseErrs, &MatchParseError{MatchURL: match.URL, Reason: err.Error()})
+			continue
+		}
+		parseErrs = append(parseErrs, statErrs...)
+		matchObj := Match{
+			Teams:      teams,
+			MatchURL:   match.URL,
+			Rounds:     rounds,
+			Map:        normalizeMapName(match.Map),
+			DataSource: parseDataSource(match.DataSource),
 		}
+		if playedAt, ok := parseMatchTime(match.PlayedAt); ok {
+			matchObj.PlayedAt = playedAt
+		}
+		matches = append(matches, matchObj)
+	}
+	return matches, parseErrs
+}
 
-		if len(validMatches) < 10 {
-			log.Printf("Skipping match %s: only %d valid players (need 10)", match.URL, len(validMatches))
-			continue
+// parseScoreboard reads the two teams of a match page and orders them winner
+// first, along with the rounds each won. The winner is the team with the
+// higher score in its header, or in the match score, which lists the teams in
+// page order. A page reading "TIE" with no score is still taken as a tie.
+// Cells that fail to parse are returned as StatParseErrors and don't fail the
+// scoreboard.
+func parseScoreboard(match ScrapedMatchData) ([2]Team, [2]int, []error, error) {
+	var teams [2]Team
+	var rounds [2]int
+	var statErrs []error
+	if len(match.Teams) != 2 {
+		return teams, rounds, nil, fmt.Errorf("found %d teams on the scoreboard; want 2", len(match.Teams))
+	}
+
+	for i, scraped := range match.Teams {
+		players, errs, err := parseTeamPlayers(match.URL, scraped)
+		if err != nil {
+			return teams, rounds, nil, fmt.Errorf("team %d: %w", i+1, err)
 		}
+		teams[i].Players = players
+		statErrs = append(statErrs, errs...)
+	}
 
-		var winPlayers, losePlayers []PlayerStats
-		for i, player := range validMatches[:10] {
-			p := PlayerStats{
-				Name:                player[0],
-				LeetifyRating:       player[1],
-				PersonalPerformance: player[2],
-				HLTVRating:          player[3],
-				KD:                  player[4],
-				ADR:                 player[5],
-				Aim:                 player[6],
-				Utility:             player[7],
-			}
-			if i < 5 {
-				winPlayers = append(winPlayers, p)
-			} else {
-				losePlayers = append(losePlayers, p)
-			}
+	a, errA := strconv.Atoi(strings.TrimSpace(match.Teams[0].Score))
+	b, errB := strconv.Atoi(strings.TrimSpace(match.Teams[1].Score))
+	hasScore := errA == nil && errB == nil
+	if !hasScore {
+		a, b, hasScore = parseScore(match.Score)
+	}
+
+	switch {
+	case hasScore && a == b, !hasScore && match.Result == "TIE":
+		teams[0].Outcome, teams[1].Outcome = OutcomeTie, OutcomeTie
+	case hasScore && a > b:
+		teams[0].Outcome, teams[1].Outcome = OutcomeWin, OutcomeLoss
+	case hasScore:
+		teams[0], teams[1] = teams[1], teams[0]
+		a, b = b, a
+		teams[0].Outcome, teams[1].Outcome = OutcomeWin, OutcomeLoss
+	default:
+		return teams, rounds, nil, fmt.Errorf("no score to tell the winner from")
+	}
+	if hasScore {
+		rounds = [2]int{a, b}
+	}
+	return teams, rounds, statErrs, nil
+}
+
+// parseTeamPlayers reads a team's rows, skipping the empty ones Leetify
+// renders between sections. A team must have exactly five players. A cell
+// that fails to parse is left missing and returned as a StatParseError.
+func parseTeamPlayers(matchURL string, team ScrapedTeam) ([]PlayerStats, []error, error) {
+	var players []PlayerStats
+	var statErrs []error
+	for i, row := range team.Rows {
+		if len(row) == 0 {
+			continue
 		}
-		winTeam := Team{
-			Players: winPlayers,
-			Won:     true,
+		if len(row) < scoreboardColumns {
+			return nil, nil, fmt.Errorf("row %d has %d columns; want %d", i+1, len(row), scoreboardColumns)
 		}
-		loseTeam := Team{
-			Players: losePlayers,
-			Won:     false,
+		player := PlayerStats{Name: row[0]}
+		if i < len(team.SteamIDs) {
+			player.SteamID = team.SteamIDs[i]
 		}
-		matchObj := Match{
-			Teams:    [2]Team{winTeam, loseTeam},
-			MatchURL: match.URL,
+		for _, metric := range metrics {
+			stat, err := parseStat(row[metric.Column])
+			if err != nil {
+				statErrs = append(statErrs, &StatParseError{
+					MatchURL: matchURL,
+					Player:   player.Name,
+					Column:   metric.Name,
+					Value:    row[metric.Column],
+					Err:      err,
+				})
+			}
+			*metric.Stat(&player) = stat
 		}
-		matches = append(matches, matchObj)
+		players = append(players, player)
+	}
+	if len(players) != 5 {
+		return nil, nil, fmt.Errorf("found %d players; want 5", len(players))
 	}
+	return players, statErrs, nil
+}
 
-	log.Printf("Successfully processed %d matches out of %d scraped", len(matches), len(allMatches))
-	return matches, nil
+// normalizeMapName turns Leetify's display name, e.g. "Dust II", into the
+// map's file name, "de_dust2".
+func normalizeMapName(raw string) string {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	name = strings.ReplaceAll(name, "dust ii", "dust2")
+	name = strings.ReplaceAll(name, " ", "")
+	if name != "" && !strings.Contains(name, "_") {
+		name = "de_" + name
+	}
+	return name
+}
+
+var scorePattern = regexp.MustCompile(`(\d+)\s*[-:]\s*(\d+)`)
+
+// parseScore reads a final score such as "13 : 9" or "16-14".
+func parseScore(raw string) (int, int, bool) {
+	m := scorePattern.FindStringSubmatch(raw)
+	if m == nil {
+		return 0, 0, false
+	}
+	a, _ := strconv.Atoi(m[1])
+	b, _ := strconv.Atoi(m[2])
+	return a, b, true
+}
+
+// matchTimeLayouts are the match date formats seen on Leetify, the datetime
+// attribute first.
+var matchTimeLayouts = []string{
+	time.RFC3339,
+	"Jan 2, 2006, 3:04 PM",
+	"Jan 2, 2006 3:04 PM",
+	"2 Jan 2006, 15:04",
+	"2006-01-02 15:04",
+}
+
+// parseMatchTime reads when a match was played. Dates without a zone are
+// taken as UTC.
+func parseMatchTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range matchTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDataSource maps Leetify's source label to "faceit", "premier" or
+// "matchmaking", or "" when unrecognised.
+func parseDataSource(raw string) string {
+	source := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case strings.Contains(source, "faceit"):
+		return "faceit"
+	case strings.Contains(source, "premier"):
+		return "premier"
+	case strings.Contains(source, "matchmaking"), strings.Contains(source, "competitive"), source == "mm":
+		return "matchmaking"
+	}
+	return ""
 }
 
 func matchesWorker(ctx context.Context, jobs <-chan string, results chan<- ScrapedMatchData) {
@@ -175,17 +462,56 @@ func matchesWorker(ctx context.Context, jobs <-chan string, results chan<- Scrap
 			tabCtx, cancel := chromedp.NewContext(timeoutCtx)
 
 			var matchResult string
-			var matchData [][]string
+			var teams []ScrapedTeam
+			var details ScrapedMatchDetails
 			err := chromedp.Run(tabCtx,
 				chromedp.Navigate(matchLink),
 				chromedp.WaitVisible(`table`, chromedp.ByQuery),
 				chromedp.Sleep(1*time.Second),
 				chromedp.Text(`div.phrase`, &matchResult, chromedp.NodeVisible, chromedp.ByQuery),
+				// a header row (one without td cells) or a new tbody starts a team
+				chromedp.Evaluate(`
+					(() => {
+						const teams = [];
+						let team = null;
+						const startTeam = header => {
+							team = {
+								name: header?.querySelector('[class*="team-name"]')?.textContent.trim() ?? '',
+								score: header?.querySelector('[class*="score"]')?.textContent.trim() ?? '',
+								rows: [],
+								steam_ids: [],
+							};
+							teams.push(team);
+						};
+						document.querySelectorAll('table tbody').forEach(tbody => {
+							team = null;
+							tbody.querySelectorAll('tr').forEach(row => {
+								const cells = Array.from(row.querySelectorAll('td'));
+								if (cells.length === 0) {
+									startTeam(row);
+									return;
+								}
+								if (!team) startTeam(null);
+								const a = row.querySelector('a[href*="/app/profile/"]');
+								team.rows.push(cells.map(cell => cell.textContent.trim()));
+								team.steam_ids.push(a ? a.href.split('/').pop() : '');
+							});
+						});
+						return teams;
+					})()
+				`, &teams),
 				chromedp.Evaluate(`
-					Array.from(document.querySelectorAll('table tbody tr'))
-						.map(row => Array.from(row.querySelectorAll('td'))
-						.map(cell => cell.textContent.trim()))
-				`, &matchData),
+					(() => {
+						const text = sel => document.querySelector(sel)?.textContent.trim() ?? '';
+						const time = document.querySelector('time');
+						return {
+							map: text('.map-name'),
+							score: text('.score'),
+							played_at: time ? (time.getAttribute('datetime') || time.textContent.trim()) : '',
+							data_source: text('.data-source'),
+						};
+					})()
+				`, &details),
 			)
 			cancel()
 			timeoutCancel()
@@ -193,29 +519,27 @@ func matchesWorker(ctx context.Context, jobs <-chan string, results chan<- Scrap
 				log.Println("Error: ", err)
 				continue
 			}
-			if matchResult == "TIE" {
-				log.Println("Tie detected, skipping...")
-				continue
-			}
 			results <- ScrapedMatchData{
-				Data: matchData,
-				URL:  matchLink,
+				Result:              matchResult,
+				Teams:               teams,
+				URL:                 matchLink,
+				ScrapedMatchDetails: details,
 			}
 		}
 	}
 }
 
-func (s *Server) scrapeMatchLinksWithWorkers(parentCtx context.Context, playerURLs []string) ([]string, error) {
+func scrapeMatchLinksWithWorkers(parentCtx context.Context, playerURLs []string, limits MatchLinkLimits, maxLoadMore int) ([]MatchLink, error) {
 	numWorkers := 5
 	jobs := make(chan string, len(playerURLs))
-	results := make(chan []string, len(playerURLs))
+	results := make(chan []MatchLink, len(playerURLs))
 
 	var wg sync.WaitGroup
 	for range numWorkers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			matchLinkWorker(parentCtx, jobs, results)
+			matchLinkWorker(parentCtx, jobs, results, limits, maxLoadMore)
 		}()
 	}
 
@@ -238,7 +562,7 @@ func (s *Server) scrapeMatchLinksWithWorkers(parentCtx context.Context, playerUR
 		close(results)
 	}
 
-	var matchLinks []string
+	var matchLinks []MatchLink
 	timeout := time.After(10 * time.Minute)
 resultsLoop:
 	for {
@@ -258,171 +582,215 @@ resultsLoop:
 		}
 	}
 
-	// only append unique matches
+	return matchLinks, nil
+}
+
+// uniqueLinks drops repeated links, since teammates surface the same match.
+func uniqueLinks(links []string) []string {
 	seen := make(map[string]bool)
 	var uniqueMatchLinks []string
-	for _, link := range matchLinks {
+	for _, link := range links {
 		if !seen[link] {
 			seen[link] = true
 			uniqueMatchLinks = append(uniqueMatchLinks, link)
 		}
 	}
+	return uniqueMatchLinks
+}
+
+// profileMatchLinks pairs each match link with the profile it was found on,
+// dropping repeats.
+func profileMatchLinks(profileURL string, links []string) []MatchLink {
+	var matchLinks []MatchLink
+	for _, link := range uniqueLinks(links) {
+		matchLinks = append(matchLinks, MatchLink{ProfileURL: profileURL, MatchURL: link})
+	}
+	return matchLinks
+}
+
+// matchURLs returns the distinct match pages among links.
+func matchURLs(links []MatchLink) []string {
+	var urls []string
+	for _, link := range links {
+		urls = append(urls, link.MatchURL)
+	}
+	return uniqueLinks(urls)
+}
+
+// defaultMaxLoadMore is how many times a profile is asked for older matches
+// unless LEETIFY_MAX_LOAD_MORE says otherwise. Each ask is followed by a
+// loadMoreWait pause for them to render.
+const (
+	defaultMaxLoadMore = 20
+	loadMoreWait       = 1500 * time.Millisecond
+)
+
+// parseMaxLoadMore parses LEETIFY_MAX_LOAD_MORE. An empty value selects
+// defaultMaxLoadMore.
+func parseMaxLoadMore(raw string) (int, error) {
+	if raw == "" {
+		return defaultMaxLoadMore, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid LEETIFY_MAX_LOAD_MORE %q: must be a number of loads", raw)
+	}
+	return n, nil
+}
 
-	return uniqueMatchLinks, nil
+// profileLinks is what matchLinksScript returns. Truncated is set when the
+// profile still had older matches to load for limits but ran out of loads.
+type profileLinks struct {
+	Links     []profileLink `json:"links"`
+	Truncated bool          `json:"truncated"`
 }
 
-func matchLinkWorker(ctx context.Context, jobs <-chan string, results chan<- []string) {
-	for matchURL := range jobs {
+// matchLinksScript collects the match links on a profile with the date of
+// each, scrolling or clicking "load more" until the page has limits.Depth
+// distinct matches, its oldest match is before limits.Since, it stops growing
+// or it has been asked maxLoadMore times.
+func matchLinksScript(limits MatchLinkLimits, maxLoadMore int) string {
+	var since int64
+	if !limits.Since.IsZero() {
+		since = limits.Since.UnixMilli()
+	}
+	return fmt.Sprintf(`
+	(async () => {
+		const depth = %d, since = %d, rounds = %d, wait = %d;
+		const collect = () => {
+			const seen = new Set();
+			const links = [];
+			for (const a of document.querySelectorAll('a.ng-star-inserted[href^="/app/match-details/"]')) {
+				if (seen.has(a.href)) continue;
+				seen.add(a.href);
+				const row = a.closest('tr');
+				const time = row && row.querySelector('time');
+				links.push({
+					url: a.href,
+					played_at: time ? (time.getAttribute('datetime') || time.textContent.trim()) : '',
+				});
+			}
+			return links;
+		};
+		const enough = links => {
+			if (depth > 0 && links.length >= depth) return true;
+			const last = links[links.length - 1];
+			return since > 0 && last !== undefined && Date.parse(last.played_at) < since;
+		};
+
+		let links = collect();
+		for (let i = 0; i < rounds && !enough(links); i++) {
+			const more = Array.from(document.querySelectorAll('button'))
+				.find(b => /load more|show more/i.test(b.textContent));
+			if (more) {
+				more.click();
+			} else {
+				window.scrollTo(0, document.body.scrollHeight);
+			}
+			await new Promise(resolve => setTimeout(resolve, wait));
+			const next = collect();
+			// nothing older to load
+			if (next.length === links.length) return { links, truncated: false };
+			links = next;
+		}
+		return { links, truncated: !enough(links) };
+	})()
+	`, limits.Depth, since, maxLoadMore, loadMoreWait.Milliseconds())
+}
+
+func matchLinkWorker(ctx context.Context, jobs <-chan string, results chan<- []MatchLink, limits MatchLinkLimits, maxLoadMore int) {
+	script := matchLinksScript(limits, maxLoadMore)
+	for profileURL := range jobs {
 		select {
 		case <-ctx.Done():
 			log.Println("Context cancelled, stopping matchLink worker")
 			return
 		default:
-			timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 30*time.Second)
+			timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 30*time.Second+time.Duration(maxLoadMore)*loadMoreWait)
 			tabCtx, cancel := chromedp.NewContext(timeoutCtx)
 
-			var links []string
+			var links profileLinks
 			err := chromedp.Run(tabCtx,
-				chromedp.Navigate(matchURL),
+				chromedp.Navigate(profileURL),
 				chromedp.WaitVisible(`table`, chromedp.ByQuery),
-				chromedp.Evaluate(`
-				Array.from(document.querySelectorAll('a.ng-star-inserted[href^="/app/match-details/"]'))
-					.slice(0, 5)
-					.map(a => a.href)
-			`, &links),
+				chromedp.Evaluate(script, &links, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+					return p.WithAwaitPromise(true)
+				}),
 			)
 			timeoutCancel()
 			cancel()
 			if err != nil {
 				log.Println("Error: ", err)
-				results <- []string{}
+				results <- []MatchLink{}
 				continue
 			}
 
-			results <- links
+			if links.Truncated {
+				log.Printf("Warning: stopped loading %s after %d loads, before reaching the match limits; older matches were not collected", profileURL, maxLoadMore)
+			}
+			results <- profileMatchLinks(profileURL, limits.apply(links.Links))
 		}
 	}
 }
 
-func getAverageMatchStats(matches []Match) ([]MatchAverageStats, error) {
+// getAverageMatchStats aggregates each team's metrics over the players that
+// have a value, so a missing or unparseable cell only drops that cell. A match
+// is skipped, with a MatchParseError, when a team has no values at all for a
+// metric that isn't Nullable.
+func getAverageMatchStats(matches []Match) ([]MatchAverageStats, []error) {
 	var matchesAverageStats []MatchAverageStats
-	const teamSize float64 = 5.0
+	var parseErrs []error
 	for _, match := range matches {
-		var (
-			winLeetify, winPersonalPerformance, winHLTV, winKD, winAim, winUtility       float64
-			lossLeetify, lossPersonalPerformance, lossHLTV, lossKD, lossAim, lossUtility float64
-			skipMatch                                                                    bool
-		)
-
-		for _, player := range match.Teams[0].Players {
-			lr, err := strconv.ParseFloat(player.LeetifyRating, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			winLeetify += lr
-
-			pp, err := strconv.ParseFloat(player.PersonalPerformance, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			winPersonalPerformance += pp
-
-			hr, err := strconv.ParseFloat(player.HLTVRating, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			winHLTV += hr
-
-			kdr, err := strconv.ParseFloat(player.KD, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			winKD += kdr
-
-			aim, err := strconv.ParseFloat(player.Aim, 64)
-			if err != nil {
-				skipMatch = true
-				break
+		stats := MatchAverageStats{
+			MatchURL: match.MatchURL,
+			Tie:      match.Teams[0].Outcome == OutcomeTie,
+		}
+		var teamErr error
+		for i, team := range match.Teams {
+			stats.Teams[i] = teamStats(team.Players)
+			for _, metric := range metrics {
+				if !metric.Nullable && stats.Teams[i][metric.Name].N == 0 {
+					teamErr = fmt.Errorf("team %d: no player has a %s value", i+1, metric.Name)
+					break
+				}
 			}
-			winAim += aim
-
-			util, err := strconv.ParseFloat(player.Utility, 64)
-			if err != nil {
-				skipMatch = true
+			if teamErr != nil {
 				break
 			}
-			winUtility += util
 		}
-		if skipMatch {
+		if teamErr != nil {
+			log.Printf("Skipping match %s: %s", match.MatchURL, teamErr)
+			parseErrs = append(parseErrs, &MatchParseError{MatchURL: match.MatchURL, Reason: teamErr.Error()})
 			continue
 		}
+		matchesAverageStats = append(matchesAverageStats, stats)
+	}
+	return matchesAverageStats, parseErrs
+}
 
-		for _, player := range match.Teams[1].Players {
-			lr, err := strconv.ParseFloat(player.LeetifyRating, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossLeetify += lr
-
-			pp, err := strconv.ParseFloat(player.PersonalPerformance, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossPersonalPerformance += pp
-
-			hr, err := strconv.ParseFloat(player.HLTVRating, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossHLTV += hr
-
-			kdr, err := strconv.ParseFloat(player.KD, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossKD += kdr
-
-			aim, err := strconv.ParseFloat(player.Aim, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossAim += aim
-
-			util, err := strconv.ParseFloat(player.Utility, 64)
-			if err != nil {
-				skipMatch = true
-				break
-			}
-			lossUtility += util
+// getMatchPlayerParams turns every player of a match into a match_players row.
+// Stats that are missing or fail to parse are stored as NULL rather than
+// dropping the player.
+func getMatchPlayerParams(match Match) []database.CreateMatchPlayerParams {
+	var params []database.CreateMatchPlayerParams
+	for team, t := range match.Teams {
+		for _, player := range t.Players {
+			params = append(params, database.CreateMatchPlayerParams{
+				MatchUrl:            match.MatchURL,
+				PlayerName:          player.Name,
+				SteamID:             sql.NullString{String: player.SteamID, Valid: player.SteamID != ""},
+				Team:                int64(team),
+				Won:                 t.Outcome == OutcomeWin,
+				Tie:                 t.Outcome == OutcomeTie,
+				LeetifyRating:       player.LeetifyRating.nullFloat(),
+				PersonalPerformance: player.PersonalPerformance.nullFloat(),
+				HltvRating:          player.HLTVRating.nullFloat(),
+				Kd:                  player.KD.nullFloat(),
+				Adr:                 player.ADR.nullFloat(),
+				Aim:                 player.Aim.nullFloat(),
+				Utility:             player.Utility.nullFloat(),
+			})
 		}
-		if skipMatch {
-			log.Println("Skipping match")
-			continue
-		}
-		matchesAverageStats = append(matchesAverageStats, MatchAverageStats{
-			MatchURL:                   match.MatchURL,
-			WinAvgLeetifyRating:        winLeetify / teamSize,
-			WinAvgPersonalPerformance:  winPersonalPerformance / teamSize,
-			WinAvgHTLVRating:           winHLTV / teamSize,
-			WinAvgKD:                   winKD / teamSize,
-			WinAvgAim:                  winAim / teamSize,
-			WinAvgUtility:              winUtility / teamSize,
-			LossAvgLeetifyRating:       lossLeetify / teamSize,
-			LossAvgPersonalPerformance: lossPersonalPerformance / teamSize,
-			LossAvgHTLVRating:          lossHLTV / teamSize,
-			LossAvgKD:                  lossKD / teamSize,
-			LossAvgAim:                 lossAim / teamSize,
-			LossAvgUtility:             lossUtility / teamSize,
-		})
-	}
-	return matchesAverageStats, nil
+	}
+	return params
 }
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeMapName(t *testing.T) {
+	for raw, want := range map[string]string{
+		"Mirage":     "de_mirage",
+		" Dust II ":  "de_dust2",
+		"de_ancient": "de_ancient",
+		"cs_office":  "cs_office",
+		"":           "",
+	} {
+		if got := normalizeMapName(raw); got != want {
+			t.Errorf("normalizeMapName(%q) = %q; want %q", raw, got, want)
+		}
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	for raw, want := range map[string][2]int{
+		"13 : 9": {13, 9},
+		"16-14":  {16, 14},
+		"9 - 13": {9, 13},
+	} {
+		a, b, ok := parseScore(raw)
+		if !ok || [2]int{a, b} != want {
+			t.Errorf("parseScore(%q) = %d, %d, %v; want %v", raw, a, b, ok, want)
+		}
+	}
+	if _, _, ok := parseScore("WIN"); ok {
+		t.Error("expected no score in WIN")
+	}
+}
+
+func TestParseMatchTime(t *testing.T) {
+	want := time.Date(2025, 7, 14, 20, 31, 0, 0, time.UTC)
+	for _, raw := range []string{"2025-07-14T20:31:00Z", "2025-07-14T22:31:00+02:00", "Jul 14, 2025, 8:31 PM"} {
+		got, ok := parseMatchTime(raw)
+		if !ok || !got.Equal(want) {
+			t.Errorf("parseMatchTime(%q) = %s, %v; want %s", raw, got, ok, want)
+		}
+	}
+	if _, ok := parseMatchTime("yesterday"); ok {
+		t.Error("expected yesterday to be unparseable")
+	}
+}
+
+func TestParseDataSource(t *testing.T) {
+	for raw, want := range map[string]string{
+		"FACEIT":      "faceit",
+		"CS2 Premier": "premier",
+		"Matchmaking": "matchmaking",
+		"MM":          "matchmaking",
+		"":            "",
+	} {
+		if got := parseDataSource(raw); got != want {
+			t.Errorf("parseDataSource(%q) = %q; want %q", raw, got, want)
+		}
+	}
+}
+
+// scrapedTeam builds a scoreboard section of n players named prefix0...
+func scrapedTeam(prefix, score string, n int) ScrapedTeam {
+	team := ScrapedTeam{Score: score}
+	for i := range n {
+		team.Rows = append(team.Rows, []string{fmt.Sprint(prefix, i), "1", "1", "1", "1", "80", "70", "50"})
+	}
+	return team
+}
+
+func TestParseScoreboard(t *testing.T) {
+	teams, rounds, _, err := parseScoreboard(ScrapedMatchData{Teams: []ScrapedTeam{
+		scrapedTeam("b", "9", 5),
+		scrapedTeam("a", "13", 5),
+	}})
+	if err != nil {
+		t.Fatalf("error parsing scoreboard. Err: %v", err)
+	}
+	if teams[0].Players[0].Name != "a0" || teams[0].Outcome != OutcomeWin || teams[1].Outcome != OutcomeLoss || rounds != [2]int{13, 9} {
+		t.Errorf("expected the second section to win 13-9; got %+v, %v", teams, rounds)
+	}
+
+	teams, rounds, _, err = parseScoreboard(ScrapedMatchData{ScrapedMatchDetails: ScrapedMatchDetails{Score: "12 : 12"}, Teams: []ScrapedTeam{
+		scrapedTeam("a", "", 5),
+		scrapedTeam("b", "", 5),
+	}})
+	if err != nil || teams[0].Outcome != OutcomeTie || teams[0].Players[0].Name != "a0" || rounds != [2]int{12, 12} {
+		t.Errorf("expected a tie in page order from the match score; got %+v, %v, %v", teams, rounds, err)
+	}
+
+	for name, match := range map[string]ScrapedMatchData{
+		"one team":     {Teams: []ScrapedTeam{scrapedTeam("a", "13", 10)}},
+		"four players": {Teams: []ScrapedTeam{scrapedTeam("a", "13", 5), scrapedTeam("b", "9", 4)}},
+		"no score":     {Result: "WIN", Teams: []ScrapedTeam{scrapedTeam("a", "", 5), scrapedTeam("b", "", 5)}},
+	} {
+		if _, _, _, err := parseScoreboard(match); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseScrapedMatchesReportsParseErrors(t *testing.T) {
+	matches, parseErrs := parseScrapedMatches([]ScrapedMatchData{
+		{URL: "ok", Teams: []ScrapedTeam{scrapedTeam("a", "13", 5), scrapedTeam("b", "9", 5)}},
+		{URL: "short", Teams: []ScrapedTeam{scrapedTeam("a", "13", 5), scrapedTeam("b", "9", 6)}},
+	})
+	if len(matches) != 1 || matches[0].MatchURL != "ok" {
+		t.Errorf("expected only the ok match; got %+v", matches)
+	}
+	var parseErr *MatchParseError
+	if len(parseErrs) != 1 || !errors.As(parseErrs[0], &parseErr) || parseErr.MatchURL != "short" {
+		t.Errorf("expected a parse error for the short match; got %v", parseErrs)
+	}
+}
+
+func TestParseMaxLoadMore(t *testing.T) {
+	if n, err := parseMaxLoadMore(""); err != nil || n != defaultMaxLoadMore {
+		t.Errorf("expected the default cap; got %d, %v", n, err)
+	}
+	if n, err := parseMaxLoadMore("50"); err != nil || n != 50 {
+		t.Errorf("expected 50; got %d, %v", n, err)
+	}
+	for _, raw := range []string{"-1", "lots"} {
+		if _, err := parseMaxLoadMore(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
@@ -4,175 +4,329 @@ import (
 	"context"
 	"cs2-stat/internal/database"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
+	"path"
+	"slices"
 	"strings"
 	"time"
+)
 
-	"github.com/chromedp/chromedp"
+// leaderboardDepth is how many players of each region's leaderboard a job
+// scrapes, in windows of leaderboardWindow players.
+const (
+	leaderboardDepth  = 2000
+	leaderboardWindow = 50
 )
 
-func (s *Server) FetchAndScrapeJob() error {
-	leaderboardStart := 0
-	leaderboardEnd := 2000
-	offset := 50
-	fetchLimit := 50
+// leaderboardPause is the wait between two leaderboard windows.
+var leaderboardPause = 2 * time.Second
 
+func (s *Server) FetchAndScrapeJob(ctx context.Context) error {
 	log.Println("Starting fetching and scraping...")
 	log.Println()
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(),
-		append(chromedp.DefaultExecAllocatorOptions[:],
-			chromedp.Flag("headless", true),
-			chromedp.Flag("disable-gpu", true),
-			chromedp.Flag("no-sandbox", true),
-			chromedp.Flag("disable-dev-shm-usage", true),
-			chromedp.Flag("disable-web-security", true),
-			chromedp.Flag("disable-features", "VizDisplayCompositor"),
-			chromedp.Flag("disable-background-timer-throttling", true),
-			chromedp.Flag("disable-backgrounding-occluded-windows", true),
-			chromedp.Flag("disable-renderer-backgrounding", true),
-			chromedp.Flag("disable-ipc-flooding-protection", true),
-		)...,
-	)
-	defer allocCancel()
-
-	scrapeCtx, scrapeCancel := chromedp.NewContext(
-		allocCtx,
-		chromedp.WithLogf(log.Printf),
-	)
-	defer scrapeCancel()
-
-	heartbeatCtx, heartbeatCancel := context.WithCancel(scrapeCtx)
-	defer heartbeatCancel()
-
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-heartbeatCtx.Done():
-				return
-			case <-ticker.C:
-				var version string
-				if err := chromedp.Run(heartbeatCtx, chromedp.Evaluate(`navigator.userAgent`, &version)); err != nil {
-					log.Printf("Heartbeat failed: %v", err)
-				}
-			}
+	for _, resource := range s.jobResources() {
+		if err := resource.Start(); err != nil {
+			return fmt.Errorf("error: failed to start match source: %w", err)
 		}
-	}()
+		defer resource.Close()
+	}
 
-	for startPos := leaderboardStart; startPos < leaderboardEnd; startPos += offset {
-		log.Printf("Scraping leaderboard position: %d to %d...", startPos+1, startPos+offset)
+	regions := s.regions
+	if len(regions) == 0 {
+		regions = []string{defaultFaceitRegion}
+	}
 
-		if startPos > leaderboardStart {
-			time.Sleep(2 * time.Second)
-		}
+	first := true
+	succeeded := 0
+	for _, region := range regions {
+		for startPos := 0; startPos < leaderboardDepth; startPos += leaderboardWindow {
+			endPos := startPos + leaderboardWindow
+			log.Printf("Scraping %s leaderboard position: %d to %d...", region, startPos+1, endPos)
+
+			if !first {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(leaderboardPause):
+				}
+			}
+			first = false
+			s.scrape.update(func(status *ScrapeStatus) {
+				status.Region = region
+				status.WindowStart = startPos + 1
+				status.WindowEnd = endPos
+			})
+
+			ranked, err := s.FetchAndScrape(ctx, region, startPos, leaderboardWindow)
+			if isFaceitAuthError(err) {
+				// every later request would be rejected the same way
+				return err
+			}
+			if ctx.Err() != nil {
+				// the window failed, or was cut short, because the job stopped
+				return ctx.Err()
+			}
+			if err != nil {
+				log.Printf("Error in %s iteration %d-%d: %v", region, startPos+1, endPos, err)
+				s.scrape.addError(fmt.Errorf("%s positions %d-%d: %w", region, startPos+1, endPos, err))
+				continue
+			}
 
-		err := s.FetchAndScrape(startPos, fetchLimit, scrapeCtx)
-		if err != nil {
-			log.Printf("Error in iteration %d-%d: %v", startPos+1, startPos+offset, err)
-			continue
+			log.Printf("Successfully completed %s iteration %d-%d", region, startPos+1, endPos)
+			succeeded++
+			if ranked < leaderboardWindow {
+				log.Printf("Reached the end of the %s leaderboard at position %d", region, startPos+ranked)
+				break
+			}
 		}
-
-		log.Printf("Successfully completed iteration %d-%d", startPos+1, startPos+offset)
 	}
 
+	if succeeded == 0 {
+		return errors.New("error: no leaderboard window succeeded")
+	}
 	log.Println("Fetching and scraping finished.")
 	return nil
 }
 
-func (s *Server) FetchAndScrape(startPos int, faceitLimit int, scrapeCtx context.Context) error {
-	parentCtx := context.Background()
+// FetchAndScrape scrapes faceitLimit players of region's leaderboard from
+// startPos. It returns how many players the leaderboard had in that range,
+// which is less than faceitLimit past its end.
+func (s *Server) FetchAndScrape(parentCtx context.Context, region string, startPos int, faceitLimit int) (int, error) {
 	ctx, cancel := context.WithTimeout(parentCtx, 5*time.Minute)
 	defer cancel()
 
-	client := &http.Client{}
-
 	// fetch top players on faceit leaderboard
-	playersEU, err := s.getTopPlayers(ctx, client, "EU", faceitLimit, startPos)
+	topPlayers, err := s.faceit.TopPlayers(ctx, region, startPos, faceitLimit)
 	if err != nil {
-		return fmt.Errorf("error: failed to get top EU players: %s", err)
+		return 0, fmt.Errorf("error: failed to get top %s players: %w", region, err)
 	}
 
-	// take resulting player IDs and extract them into a slice
+	// take resulting player IDs and extract them into a slice, keeping each
+	// player's ranking entry for their Elo and position
 	playerIDs := []string{}
-	for _, player := range playersEU.Items {
+	rankings := make(map[string]int)
+	for i, player := range topPlayers.Items {
 		playerIDs = append(playerIDs, player.PlayerID)
+		rankings[player.PlayerID] = i
 	}
 
 	// get player details (steamID) from faceit
-	playerDetails, err := s.getPlayerDetailsWithWorkers(ctx, client, playerIDs)
+	playerDetails, err := s.getPlayerDetailsWithWorkers(ctx, playerIDs)
 	if err != nil {
-		return fmt.Errorf("error: failed to get player details: %s", err)
+		return 0, fmt.Errorf("error: failed to get player details: %w", err)
 	}
+	s.scrape.update(func(status *ScrapeStatus) {
+		status.PlayersFetched += len(playerDetails)
+	})
+
+	// players.steam_id rejects anything but a SteamID64, and players without
+	// one have no Leetify profile to scrape
+	playerDetails = slices.DeleteFunc(playerDetails, func(player PlayerDetails) bool {
+		if validSteamID64(player.SteamID64) {
+			return false
+		}
+		log.Printf("Skipping player %s: invalid steam ID %q", player.Nickname, player.SteamID64)
+		return true
+	})
 
+	capturedAt := s.scrape.startedAt()
 	for _, player := range playerDetails {
 		faceitURL := strings.ReplaceAll(player.FaceitURL, "{lang}", "en")
-		_, err := s.db.CreatePlayer(ctx, database.CreatePlayerParams{
+		params := database.CreatePlayerParams{
 			SteamID:   player.SteamID64,
 			Name:      player.Nickname,
 			Country:   player.Country,
 			FaceitUrl: faceitURL,
 			Avatar:    player.Avatar,
-		})
+			Region:    sql.NullString{String: region, Valid: true},
+		}
+		i, ranked := rankings[player.PlayerID]
+		if ranked {
+			ranking := topPlayers.Items[i]
+			params.FaceitElo = sql.NullInt64{Int64: int64(ranking.FaceitElo), Valid: true}
+			params.LeaderboardPosition = sql.NullInt64{Int64: int64(ranking.Position), Valid: true}
+		}
+		_, err := s.db.CreatePlayer(ctx, params)
 		if err != nil {
-			return fmt.Errorf("error: %s", err)
+			return 0, fmt.Errorf("error: %s", err)
+		}
+
+		// keep the rating history the players upsert overwrites
+		if ranked {
+			ranking := topPlayers.Items[i]
+			err := s.db.CreatePlayerSnapshot(ctx, database.CreatePlayerSnapshotParams{
+				SteamID:             player.SteamID64,
+				CapturedAt:          capturedAt,
+				Region:              region,
+				FaceitElo:           int64(ranking.FaceitElo),
+				SkillLevel:          int64(ranking.GameSkillLevel),
+				LeaderboardPosition: int64(ranking.Position),
+			})
+			if err != nil {
+				return 0, fmt.Errorf("error: failed to save player snapshot: %s", err)
+			}
 		}
 	}
 
+	// pull the same players' matches from the Faceit Data API
+	if s.matchHistory > 0 {
+		faceitCtx, faceitCancel := context.WithTimeout(parentCtx, faceitMatchesTimeout)
+		saved, err := s.saveFaceitMatches(faceitCtx, playerDetails)
+		faceitCancel()
+		if isFaceitAuthError(err) || parentCtx.Err() != nil {
+			return 0, err
+		}
+		if err != nil {
+			// the Faceit stats are a cross-check, the window goes on to Leetify
+			log.Printf("Error saving faceit matches: %v", err)
+			s.scrape.addError(fmt.Errorf("faceit matches: %w", err))
+		}
+		log.Println("Faceit matches saved:", saved)
+	}
+
 	var leetifyURLs []string
 	for _, playerDetail := range playerDetails {
 		url := leetifyUserURL + playerDetail.SteamID64
 		leetifyURLs = append(leetifyURLs, url)
 	}
 
+	saved, err := s.scrapeAndSaveMatches(parentCtx, region, leetifyURLs)
+	if err != nil {
+		return 0, err
+	}
+	log.Println("Matches analyzed and saved:", saved)
+
+	return len(topPlayers.Items), nil
+}
+
+// scrapeAndSaveMatches collects recent matches from the given Leetify profiles
+// through the server's match sources and saves their averages and player
+// lines under region. It returns the number of matches saved.
+func (s *Server) scrapeAndSaveMatches(ctx context.Context, region string, leetifyURLs []string) (int, error) {
 	log.Println("Scraping user profiles for matches...")
-	matchLinks, err := s.scrapeMatchLinksWithWorkers(scrapeCtx, leetifyURLs)
+	profileLinks, err := s.matchLinks.MatchLinks(ctx, leetifyURLs)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	matchLinks := matchURLs(profileLinks)
+	s.scrape.update(func(status *ScrapeStatus) {
+		status.MatchLinksFound += len(matchLinks)
+	})
 
 	log.Println("Scraping matches for stats...")
-	matches, err := s.scrapeMatchesWithWorkers(scrapeCtx, matchLinks)
+	matches, parseErrs, err := s.matchStats.MatchStats(ctx, matchLinks)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	s.recordScrapeErrors(parseErrs)
 
-	avgMatchStats, err := getAverageMatchStats(matches)
-	if err != nil {
-		return fmt.Errorf("error calculating average match stats: %s", err)
+	avgMatchStats, statErrs := getAverageMatchStats(matches)
+	s.recordScrapeErrors(statErrs)
+
+	// header details scraped alongside each scoreboard
+	matchesByURL := make(map[string]Match)
+	for _, match := range matches {
+		matchesByURL[match.MatchURL] = match
 	}
 
 	var matchesToInsert []database.CreateMatchParams
 	for _, match := range avgMatchStats {
+		details := matchesByURL[match.MatchURL]
+		win, loss := match.Teams[0], match.Teams[1]
 		matchesToInsert = append(matchesToInsert, database.CreateMatchParams{
 			MatchUrl:                match.MatchURL,
-			WAvgLeetifyRating:       match.WinAvgLeetifyRating,
-			WAvgPersonalPerformance: match.WinAvgPersonalPerformance,
-			WAvgHltvRating:          match.WinAvgHTLVRating,
-			WAvgKd:                  match.WinAvgKD,
-			WAvgAim:                 match.WinAvgAim,
-			WAvgUtility:             match.WinAvgUtility,
-			LAvgLeetifyRating:       match.LossAvgLeetifyRating,
-			LAvgPersonalPerformance: match.LossAvgPersonalPerformance,
-			LAvgHltvRating:          match.LossAvgHTLVRating,
-			LAvgKd:                  match.LossAvgKD,
-			LAvgAim:                 match.LossAvgAim,
-			LAvgUtility:             match.LossAvgUtility,
+			WAvgLeetifyRating:       win[metricLeetifyRating].Mean,
+			WAvgPersonalPerformance: win[metricPersonalPerformance].Mean,
+			WAvgHltvRating:          win[metricHLTVRating].Mean,
+			WAvgKd:                  win[metricKD].Mean,
+			WAvgAim:                 win[metricAim].Mean,
+			WAvgUtility:             win[metricUtility].Mean,
+			WAvgAdr:                 sql.NullFloat64{Float64: win[metricADR].Mean, Valid: win[metricADR].N > 0},
+			LAvgLeetifyRating:       loss[metricLeetifyRating].Mean,
+			LAvgPersonalPerformance: loss[metricPersonalPerformance].Mean,
+			LAvgHltvRating:          loss[metricHLTVRating].Mean,
+			LAvgKd:                  loss[metricKD].Mean,
+			LAvgAim:                 loss[metricAim].Mean,
+			LAvgUtility:             loss[metricUtility].Mean,
+			LAvgAdr:                 sql.NullFloat64{Float64: loss[metricADR].Mean, Valid: loss[metricADR].N > 0},
+			Region:                  sql.NullString{String: region, Valid: true},
+			Map:                     sql.NullString{String: details.Map, Valid: details.Map != ""},
+			WRounds:                 sql.NullInt64{Int64: int64(details.Rounds[0]), Valid: details.Rounds != [2]int{}},
+			LRounds:                 sql.NullInt64{Int64: int64(details.Rounds[1]), Valid: details.Rounds != [2]int{}},
+			PlayedAt:                sql.NullTime{Time: details.PlayedAt, Valid: !details.PlayedAt.IsZero()},
+			DataSource:              sql.NullString{String: details.DataSource, Valid: details.DataSource != ""},
+			Tie:                     match.Tie,
 		})
 	}
-	if err = BatchInsertMatches(context.Background(), s.dbConn, matchesToInsert); err != nil {
-		return fmt.Errorf("error: failed to batch insert: %w", err)
+
+	// only keep player lines for matches whose averages are being saved
+	savedMatches := make(map[string]bool)
+	for _, match := range avgMatchStats {
+		savedMatches[match.MatchURL] = true
+	}
+	var playersToInsert []database.CreateMatchPlayerParams
+	for _, match := range matches {
+		if savedMatches[match.MatchURL] {
+			playersToInsert = append(playersToInsert, getMatchPlayerParams(match)...)
+		}
 	}
-	log.Println("Matches analyzed and saved:", len(avgMatchStats))
 
-	return nil
+	// link saved matches back to the tracked players whose profiles surfaced them
+	var playerMatchesToInsert []database.CreatePlayerMatchParams
+	for _, link := range profileLinks {
+		if savedMatches[link.MatchURL] {
+			playerMatchesToInsert = append(playerMatchesToInsert, database.CreatePlayerMatchParams{
+				SteamID:  path.Base(link.ProfileURL),
+				MatchUrl: link.MatchURL,
+			})
+		}
+	}
+
+	if err = BatchInsertMatches(context.Background(), s.dbConn, matchesToInsert, playersToInsert, playerMatchesToInsert); err != nil {
+		return 0, fmt.Errorf("error: failed to batch insert: %w", err)
+	}
+	s.scrape.update(func(status *ScrapeStatus) {
+		status.MatchesSaved += len(avgMatchStats)
+	})
+
+	return len(avgMatchStats), nil
+}
+
+// recordScrapeErrors adds parse errors to the job status and saves them in
+// scrape_errors against the running job, if any.
+func (s *Server) recordScrapeErrors(errs []error) {
+	runID := s.scrape.snapshot().RunID
+	for _, err := range errs {
+		s.scrape.addError(err)
+
+		params := database.CreateScrapeErrorParams{
+			RunID:   sql.NullInt64{Int64: runID, Valid: runID != 0},
+			Message: err.Error(),
+		}
+		var matchErr *MatchParseError
+		var statErr *StatParseError
+		switch {
+		case errors.As(err, &statErr):
+			params.MatchUrl = sql.NullString{String: statErr.MatchURL, Valid: true}
+			params.PlayerName = sql.NullString{String: statErr.Player, Valid: true}
+			params.ColumnName = sql.NullString{String: statErr.Column, Valid: true}
+			params.RawValue = sql.NullString{String: statErr.Value, Valid: true}
+		case errors.As(err, &matchErr):
+			params.MatchUrl = sql.NullString{String: matchErr.MatchURL, Valid: true}
+		}
+		// saved even if the job's context was cancelled mid-scrape
+		if err := s.db.CreateScrapeError(context.Background(), params); err != nil {
+			log.Printf("error: failed to save scrape error: %s", err)
+		}
+	}
 }
 
-func BatchInsertMatches(ctx context.Context, db *sql.DB, matches []database.CreateMatchParams) error {
+// BatchInsertMatches upserts match averages, the per-player lines of those
+// matches and their links to tracked players in a single transaction.
+func BatchInsertMatches(ctx context.Context, db *sql.DB, matches []database.CreateMatchParams, players []database.CreateMatchPlayerParams, playerMatches []database.CreatePlayerMatchParams) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -187,6 +341,32 @@ func BatchInsertMatches(ctx context.Context, db *sql.DB, matches []database.Crea
 		}
 	}
 
+	for _, player := range players {
+		// a line saved before the player's steam ID was known is replaced
+		if player.SteamID.Valid {
+			err := qtx.DeleteUnlinkedMatchPlayer(ctx, database.DeleteUnlinkedMatchPlayerParams{
+				MatchUrl:   player.MatchUrl,
+				Team:       player.Team,
+				PlayerName: player.PlayerName,
+			})
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := qtx.CreateMatchPlayer(ctx, player); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, playerMatch := range playerMatches {
+		if err := qtx.CreatePlayerMatch(ctx, playerMatch); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestBatchInsertMatchesWithPlayers(t *testing.T) {
+	s := newTestServer(t)
+
+	match := Match{
+		MatchURL: "https://leetify.com/app/match-details/1",
+		Teams: [2]Team{
+			{Outcome: OutcomeWin, Players: []PlayerStats{
+				{Name: "ropz", SteamID: "76561197991272318", LeetifyRating: Stat{Value: 3.1}, KD: Stat{Value: 1.4}, ADR: Stat{Value: 91}},
+			}},
+			{Outcome: OutcomeLoss, Players: []PlayerStats{
+				{Name: "frozen", LeetifyRating: Stat{Value: -1.2}, KD: Stat{Missing: true}, ADR: Stat{Value: 70}},
+			}},
+		},
+	}
+	players := getMatchPlayerParams(match)
+	if len(players) != 2 {
+		t.Fatalf("expected 2 player lines; got %d", len(players))
+	}
+	if !players[0].Won || players[0].Team != 0 || players[1].Won || players[1].Team != 1 {
+		t.Errorf("unexpected teams %+v", players)
+	}
+	if players[1].Kd.Valid || players[1].SteamID.Valid {
+		t.Errorf("expected missing K/D and steam ID to be NULL; got %+v", players[1])
+	}
+
+	err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: match.MatchURL},
+	}, players, nil)
+	if err != nil {
+		t.Fatalf("error inserting matches. Err: %v", err)
+	}
+
+	var count int
+	var adr float64
+	err = s.dbConn.QueryRow(`SELECT COUNT(*), SUM(adr) FROM match_players WHERE match_url = ?`, match.MatchURL).Scan(&count, &adr)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if count != 2 || adr != 161 {
+		t.Errorf("expected 2 rows with total ADR 161; got %d rows, ADR %v", count, adr)
+	}
+
+	tie := Match{
+		MatchURL: "https://leetify.com/app/match-details/2",
+		Teams: [2]Team{
+			{Outcome: OutcomeTie, Players: []PlayerStats{{Name: "ropz", SteamID: "76561197991272318"}}},
+			{Outcome: OutcomeTie, Players: []PlayerStats{{Name: "frozen"}}},
+		},
+	}
+	err = BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{
+		{MatchUrl: tie.MatchURL, Tie: true},
+	}, getMatchPlayerParams(tie), nil)
+	if err != nil {
+		t.Fatalf("error inserting tied match. Err: %v", err)
+	}
+
+	var ties, wins int
+	err = s.dbConn.QueryRow(`SELECT COUNT(*) FILTER (WHERE tie), COUNT(*) FILTER (WHERE won) FROM match_players WHERE match_url = ?`, tie.MatchURL).Scan(&ties, &wins)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if ties != 2 || wins != 0 {
+		t.Errorf("expected both players of the tie marked tied, not won; got %d ties, %d wins", ties, wins)
+	}
+	var losses int
+	err = s.dbConn.QueryRow(`SELECT COUNT(*) FROM match_players WHERE NOT won AND NOT tie`).Scan(&losses)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if losses != 1 {
+		t.Errorf("expected only the real loss counted as one; got %d", losses)
+	}
+}
+
+func TestBatchInsertMatchesSameNamePlayers(t *testing.T) {
+	s := newTestServer(t)
+	url := "https://leetify.com/app/match-details/1"
+	insert := func(match Match) {
+		t.Helper()
+		err := BatchInsertMatches(context.Background(), s.dbConn, []database.CreateMatchParams{{MatchUrl: url}}, getMatchPlayerParams(match), nil)
+		if err != nil {
+			t.Fatalf("error inserting match. Err: %v", err)
+		}
+	}
+
+	insert(Match{MatchURL: url, Teams: [2]Team{
+		{Outcome: OutcomeWin, Players: []PlayerStats{
+			{Name: "player", SteamID: "76561198000000001", ADR: Stat{Value: 90}},
+			{Name: "player", SteamID: "76561198000000002", ADR: Stat{Value: 80}},
+			{Name: "anon"},
+		}},
+		{Outcome: OutcomeLoss, Players: []PlayerStats{{Name: "anon"}}},
+	}})
+	// a rescrape that resolves one anonymous player and sees a rename
+	insert(Match{MatchURL: url, Teams: [2]Team{
+		{Outcome: OutcomeWin, Players: []PlayerStats{
+			{Name: "renamed", SteamID: "76561198000000001", ADR: Stat{Value: 95}},
+			{Name: "player", SteamID: "76561198000000002", ADR: Stat{Value: 80}},
+			{Name: "anon", SteamID: "76561198000000003"},
+		}},
+		{Outcome: OutcomeLoss, Players: []PlayerStats{{Name: "anon"}}},
+	}})
+
+	var count, linked int
+	err := s.dbConn.QueryRow(`SELECT COUNT(*), COUNT(steam_id) FROM match_players WHERE match_url = ?`, url).Scan(&count, &linked)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if count != 4 || linked != 3 {
+		t.Errorf("expected 4 players, 3 with steam IDs; got %d, %d", count, linked)
+	}
+
+	var name string
+	var adr float64
+	err = s.dbConn.QueryRow(`SELECT player_name, adr FROM match_players WHERE steam_id = '76561198000000001'`).Scan(&name, &adr)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if name != "renamed" || adr != 95 {
+		t.Errorf("expected the rescrape to update the player's line; got %q, %v", name, adr)
+	}
+}
+
+func TestCreateMatchKeepsDetails(t *testing.T) {
+	s := newTestServer(t)
+	url := "https://leetify.com/app/match-details/1"
+	details := database.CreateMatchParams{
+		MatchUrl:   url,
+		Map:        sql.NullString{String: "de_inferno", Valid: true},
+		WRounds:    sql.NullInt64{Int64: 13, Valid: true},
+		LRounds:    sql.NullInt64{Int64: 7, Valid: true},
+		PlayedAt:   sql.NullTime{Time: time.Date(2025, 7, 14, 20, 31, 0, 0, time.UTC), Valid: true},
+		DataSource: sql.NullString{String: "faceit", Valid: true},
+	}
+	// the same match scraped again from another profile, with no details
+	for _, match := range []database.CreateMatchParams{details, {MatchUrl: url, WAvgKd: 1.2}} {
+		if err := s.db.CreateMatch(context.Background(), match); err != nil {
+			t.Fatalf("error inserting match. Err: %v", err)
+		}
+	}
+
+	var mapName, dataSource string
+	var wRounds, lRounds int
+	var playedAt time.Time
+	var wKD float64
+	err := s.dbConn.QueryRow(`SELECT map, w_rounds, l_rounds, played_at, data_source, w_avg_kd FROM matches WHERE match_url = ?`, url).Scan(&mapName, &wRounds, &lRounds, &playedAt, &dataSource, &wKD)
+	if err != nil {
+		t.Fatalf("error reading match. Err: %v", err)
+	}
+	if mapName != "de_inferno" || wRounds != 13 || lRounds != 7 || !playedAt.Equal(details.PlayedAt.Time) || dataSource != "faceit" {
+		t.Errorf("expected the details kept; got %s %d-%d %s %s", mapName, wRounds, lRounds, playedAt, dataSource)
+	}
+	if wKD != 1.2 {
+		t.Errorf("expected the averages updated; got w_avg_kd %v", wKD)
+	}
+}
+
+func TestFetchAndScrapeOffline(t *testing.T) {
+	s := newTestServer(t)
+	s.faceit = newFaceitStub(t, map[string]PlayerDetails{
+		"faceit-1": {PlayerID: "faceit-1", Nickname: "one", Country: "dk", SteamID64: "76561198000000001", FaceitURL: "https://www.faceit.com/{lang}/players/one"},
+		"faceit-2": {PlayerID: "faceit-2", Nickname: "two", Country: "se", SteamID64: "76561198000000002"},
+		"faceit-3": {PlayerID: "faceit-3", Nickname: "nosteam", Country: "de"},
+	})
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+
+	ranked, err := s.FetchAndScrape(context.Background(), "NA", 0, 50)
+	if err != nil {
+		t.Fatalf("error running pipeline. Err: %v", err)
+	}
+	if ranked != 3 {
+		t.Errorf("expected 3 ranked players; got %d", ranked)
+	}
+
+	var players, matches int
+	if err := s.dbConn.QueryRow(`SELECT (SELECT COUNT(*) FROM players), (SELECT COUNT(*) FROM matches)`).Scan(&players, &matches); err != nil {
+		t.Fatalf("error counting rows. Err: %v", err)
+	}
+	if players != 2 || matches != 2 {
+		t.Errorf("expected 2 players with steam IDs and 2 matches; got %d players, %d matches", players, matches)
+	}
+
+	var faceitURL, region string
+	var position sql.NullInt64
+	if err := s.dbConn.QueryRow(`SELECT faceit_url, region, leaderboard_position FROM players WHERE name = 'one'`).Scan(&faceitURL, &region, &position); err != nil {
+		t.Fatalf("error reading player. Err: %v", err)
+	}
+	if faceitURL != "https://www.faceit.com/en/players/one" {
+		t.Errorf("expected {lang} replaced in faceit url; got %s", faceitURL)
+	}
+	if region != "NA" || !position.Valid {
+		t.Errorf("expected NA player with a leaderboard position; got %s, %v", region, position)
+	}
+
+	var snapshots int
+	if err := s.dbConn.QueryRow(`SELECT COUNT(*) FROM player_snapshots WHERE region = 'NA'`).Scan(&snapshots); err != nil {
+		t.Fatalf("error counting snapshots. Err: %v", err)
+	}
+	if snapshots != 2 {
+		t.Errorf("expected a snapshot per player; got %d", snapshots)
+	}
+
+	if err := s.dbConn.QueryRow(`SELECT DISTINCT region FROM matches`).Scan(&region); err != nil {
+		t.Fatalf("error reading match. Err: %v", err)
+	}
+	if region != "NA" {
+		t.Errorf("expected match saved under NA; got %s", region)
+	}
+}
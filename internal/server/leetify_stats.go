@@ -0,0 +1,162 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Stat is a parsed scoreboard cell. Missing is set for cells Leetify leaves
+// blank or fills with a placeholder dash, which aren't parse errors.
+type Stat struct {
+	Value   float64
+	Missing bool
+}
+
+// nullFloat maps a missing stat to NULL.
+func (s Stat) nullFloat() sql.NullFloat64 {
+	return sql.NullFloat64{Float64: s.Value, Valid: !s.Missing}
+}
+
+var errStatFormat = errors.New("not a number")
+
+// statPlaceholders are the cell texts Leetify shows when it has no value.
+var statPlaceholders = map[string]bool{
+	"":    true,
+	"-":   true,
+	"–":   true, // en dash
+	"—":   true, // em dash
+	"n/a": true,
+}
+
+// parseStat reads a scoreboard number as Leetify renders it: with an explicit
+// sign ("+1.23", "−0.5"), as a percentage ("12%", kept as 12), or with a
+// decimal comma ("1,05"). Placeholders parse as a missing Stat.
+func parseStat(raw string) (Stat, error) {
+	s := strings.TrimSpace(strings.ReplaceAll(raw, "\u00a0", " "))
+	if statPlaceholders[strings.ToLower(s)] {
+		return Stat{Missing: true}, nil
+	}
+
+	s = strings.ReplaceAll(s, "\u2212", "-") // minus sign
+	s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+	s = strings.ReplaceAll(s, " ", "")
+	if strings.Contains(s, ",") {
+		if strings.Contains(s, ".") {
+			// "1,234.5": the comma groups thousands
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Stat{Missing: true}, errStatFormat
+	}
+	return Stat{Value: f}, nil
+}
+
+// Metric is a numeric scoreboard column. Scraping a new column only needs a
+// PlayerStats field, a metric name and an entry in metrics.
+type Metric struct {
+	Name   string // match_players column, e.g. "kd"
+	Column int    // index of the cell in a scoreboard row
+	Stat   func(p *PlayerStats) *Stat
+	// Nullable metrics are averaged into nullable matches columns, so a team
+	// without any value for them keeps the match.
+	Nullable bool
+}
+
+// Metric names, shared by the scoreboard parser and everything that reads a
+// TeamStats.
+const (
+	metricLeetifyRating       = "leetify_rating"
+	metricPersonalPerformance = "personal_performance"
+	metricHLTVRating          = "hltv_rating"
+	metricKD                  = "kd"
+	metricADR                 = "adr"
+	metricAim                 = "aim"
+	metricUtility             = "utility"
+)
+
+// metrics are the scoreboard columns after the player's name, in page order.
+var metrics = []Metric{
+	{metricLeetifyRating, 1, func(p *PlayerStats) *Stat { return &p.LeetifyRating }, false},
+	{metricPersonalPerformance, 2, func(p *PlayerStats) *Stat { return &p.PersonalPerformance }, false},
+	{metricHLTVRating, 3, func(p *PlayerStats) *Stat { return &p.HLTVRating }, false},
+	{metricKD, 4, func(p *PlayerStats) *Stat { return &p.KD }, false},
+	{metricADR, 5, func(p *PlayerStats) *Stat { return &p.ADR }, true},
+	{metricAim, 6, func(p *PlayerStats) *Stat { return &p.Aim }, false},
+	{metricUtility, 7, func(p *PlayerStats) *Stat { return &p.Utility }, false},
+}
+
+// scoreboardColumns is the number of cells a scoreboard row needs.
+var scoreboardColumns = func() int {
+	n := 1
+	for _, metric := range metrics {
+		n = max(n, metric.Column+1)
+	}
+	return n
+}()
+
+// Aggregate summarizes one metric over the players of a team that have a
+// value for it. StdDev is the population standard deviation.
+type Aggregate struct {
+	N      int
+	Mean   float64
+	Median float64
+	Min    float64
+	Max    float64
+	StdDev float64
+}
+
+func aggregate(values []float64) Aggregate {
+	if len(values) == 0 {
+		return Aggregate{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	agg := Aggregate{
+		N:   len(sorted),
+		Min: sorted[0],
+		Max: sorted[len(sorted)-1],
+	}
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	agg.Mean = sum / float64(agg.N)
+	if mid := agg.N / 2; agg.N%2 == 1 {
+		agg.Median = sorted[mid]
+	} else {
+		agg.Median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	var sq float64
+	for _, v := range sorted {
+		sq += (v - agg.Mean) * (v - agg.Mean)
+	}
+	agg.StdDev = math.Sqrt(sq / float64(agg.N))
+	return agg
+}
+
+// TeamStats holds a team's Aggregate for each metric, keyed by Metric.Name.
+type TeamStats map[string]Aggregate
+
+func teamStats(players []PlayerStats) TeamStats {
+	stats := make(TeamStats, len(metrics))
+	for _, metric := range metrics {
+		var values []float64
+		for i := range players {
+			if stat := metric.Stat(&players[i]); !stat.Missing {
+				values = append(values, stat.Value)
+			}
+		}
+		stats[metric.Name] = aggregate(values)
+	}
+	return stats
+}
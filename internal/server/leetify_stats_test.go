@@ -0,0 +1,108 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseStat(t *testing.T) {
+	for raw, want := range map[string]float64{
+		"1.23":      1.23,
+		"+1.23":     1.23,
+		"-0.5":      -0.5,
+		"\u22120.5": -0.5,
+		"12%":       12,
+		" 87 % ":    87,
+		"1,05":      1.05,
+		"1,234.5":   1234.5,
+		"0":         0,
+	} {
+		got, err := parseStat(raw)
+		if err != nil || got.Missing || got.Value != want {
+			t.Errorf("parseStat(%q) = %+v, %v; want %v", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{"", "-", "–", "—", "N/A", " "} {
+		got, err := parseStat(raw)
+		if err != nil || !got.Missing {
+			t.Errorf("parseStat(%q) = %+v, %v; want missing", raw, got, err)
+		}
+	}
+
+	for _, raw := range []string{"1.3x", "abc", "1.2.3"} {
+		if got, err := parseStat(raw); err == nil || !got.Missing {
+			t.Errorf("parseStat(%q) = %+v, %v; want error", raw, got, err)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	got := aggregate([]float64{4, 1, 3, 2})
+	want := Aggregate{N: 4, Mean: 2.5, Median: 2.5, Min: 1, Max: 4, StdDev: math.Sqrt(1.25)}
+	if got != want {
+		t.Errorf("aggregate = %+v; want %+v", got, want)
+	}
+	if got := aggregate([]float64{3, 1, 2}); got.Median != 2 || got.Mean != 2 {
+		t.Errorf("expected median and mean 2 for odd count; got %+v", got)
+	}
+	if got := aggregate(nil); got != (Aggregate{}) {
+		t.Errorf("expected zero aggregate for no values; got %+v", got)
+	}
+}
+
+func TestGetAverageMatchStatsPartialRows(t *testing.T) {
+	team := func(score string, kds ...string) ScrapedTeam {
+		scraped := ScrapedTeam{Score: score}
+		for _, kd := range kds {
+			scraped.Rows = append(scraped.Rows, []string{"p", "+1", "1", "1", kd, "80", "70%", "50"})
+		}
+		return scraped
+	}
+	scraped := ScrapedMatchData{
+		URL:   "partial",
+		Teams: []ScrapedTeam{team("13", "1.5", "—", "0,5", "bad", "1"), team("9", "1", "1", "1", "1", "1")},
+	}
+
+	matches, parseErrs := parseScrapedMatches([]ScrapedMatchData{scraped})
+	var statErr *StatParseError
+	if len(matches) != 1 || len(parseErrs) != 1 || !errors.As(parseErrs[0], &statErr) || statErr.Column != metricKD || statErr.Value != "bad" {
+		t.Fatalf("expected the match kept with one K/D parse error; got %d matches, %v", len(matches), parseErrs)
+	}
+
+	stats, parseErrs := getAverageMatchStats(matches)
+	if len(stats) != 1 || len(parseErrs) != 0 {
+		t.Fatalf("expected the partial match to be kept; got %+v, %v", stats, parseErrs)
+	}
+	if kd := stats[0].Teams[0][metricKD]; kd.N != 3 || kd.Mean != 1 || kd.Max != 1.5 {
+		t.Errorf("expected K/D over the 3 parsed values; got %+v", kd)
+	}
+	if aim := stats[0].Teams[0][metricAim]; aim.Mean != 70 || aim.StdDev != 0 {
+		t.Errorf("expected aim 70 from percentages; got %+v", aim)
+	}
+
+	scraped.Teams[1] = team("9", "—", "—", "—", "—", "—")
+	matches, _ = parseScrapedMatches([]ScrapedMatchData{scraped})
+	stats, parseErrs = getAverageMatchStats(matches)
+	var matchErr *MatchParseError
+	if len(stats) != 0 || len(parseErrs) != 1 || !errors.As(parseErrs[0], &matchErr) {
+		t.Errorf("expected the match skipped when a team has no K/D; got %+v, %v", stats, parseErrs)
+	}
+
+	noADR := ScrapedMatchData{
+		URL:   "no-adr",
+		Teams: []ScrapedTeam{team("13", "1", "1", "1", "1", "1"), team("9", "1", "1", "1", "1", "1")},
+	}
+	for _, row := range noADR.Teams[1].Rows {
+		row[5] = "—"
+	}
+	matches, _ = parseScrapedMatches([]ScrapedMatchData{noADR})
+	stats, parseErrs = getAverageMatchStats(matches)
+	if len(stats) != 1 || len(parseErrs) != 0 {
+		t.Fatalf("expected the match kept when a team has no ADR; got %+v, %v", stats, parseErrs)
+	}
+	if adr := stats[0].Teams[1][metricADR]; adr.N != 0 {
+		t.Errorf("expected no ADR for the losing team; got %+v", adr)
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// MatchLink is a match page found on a tracked player's Leetify profile.
+type MatchLink struct {
+	ProfileURL string
+	MatchURL   string
+}
+
+// MatchLinkSource finds recent match page links on Leetify profiles. A match
+// shared by several tracked players is returned once per profile.
+type MatchLinkSource interface {
+	MatchLinks(ctx context.Context, profileURLs []string) ([]MatchLink, error)
+}
+
+// defaultMatchDepth is how many of each profile's latest matches are collected
+// unless LEETIFY_MATCH_DEPTH or LEETIFY_MATCHES_SINCE say otherwise.
+const defaultMatchDepth = 5
+
+// MatchLinkLimits bound how far back into a profile's history a
+// MatchLinkSource goes: the latest Depth matches, 0 for no limit, played
+// since Since, zero for no cutoff. Matches without a readable date are kept.
+type MatchLinkLimits struct {
+	Depth int
+	Since time.Time
+}
+
+// parseMatchLinkLimits parses LEETIFY_MATCH_DEPTH and LEETIFY_MATCHES_SINCE,
+// a day such as "2025-06-01" or an RFC 3339 timestamp. The depth defaults to
+// defaultMatchDepth, or to no limit when there is a cutoff.
+func parseMatchLinkLimits(depthRaw, sinceRaw string) (MatchLinkLimits, error) {
+	var limits MatchLinkLimits
+	if sinceRaw != "" {
+		since, err := time.Parse(time.DateOnly, sinceRaw)
+		if err != nil {
+			since, err = time.Parse(time.RFC3339, sinceRaw)
+		}
+		if err != nil {
+			return limits, fmt.Errorf("invalid LEETIFY_MATCHES_SINCE %q: must be a date such as 2025-06-01 or an RFC 3339 timestamp", sinceRaw)
+		}
+		limits.Since = since.UTC()
+	}
+
+	switch {
+	case depthRaw != "":
+		depth, err := strconv.Atoi(depthRaw)
+		if err != nil || depth < 0 {
+			return limits, fmt.Errorf("invalid LEETIFY_MATCH_DEPTH %q: must be a number of matches, 0 for no limit", depthRaw)
+		}
+		limits.Depth = depth
+	case limits.Since.IsZero():
+		limits.Depth = defaultMatchDepth
+	}
+	if limits.Depth == 0 && limits.Since.IsZero() {
+		return limits, fmt.Errorf("LEETIFY_MATCH_DEPTH 0 needs LEETIFY_MATCHES_SINCE to bound the history")
+	}
+	return limits, nil
+}
+
+// profileLink is a match link on a profile and the date shown next to it.
+type profileLink struct {
+	URL      string `json:"url"`
+	PlayedAt string `json:"played_at"` // datetime attribute or text of the match date, "" when missing
+}
+
+// UnmarshalJSON also accepts a bare link, so fixtures can leave out dates.
+func (l *profileLink) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &l.URL); err == nil {
+		return nil
+	}
+	type plain profileLink
+	return json.Unmarshal(data, (*plain)(l))
+}
+
+// apply returns the distinct links within the limits, in page order.
+func (l MatchLinkLimits) apply(links []profileLink) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, link := range links {
+		if l.Depth > 0 && len(urls) == l.Depth {
+			break
+		}
+		if seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		if playedAt, ok := parseMatchTime(link.PlayedAt); ok && !l.Since.IsZero() && playedAt.Before(l.Since) {
+			continue
+		}
+		urls = append(urls, link.URL)
+	}
+	return urls
+}
+
+// MatchStatsSource reads the scoreboard of each match page. Pages whose
+// scoreboard can't be read are reported as MatchParseErrors alongside the
+// matches that could.
+type MatchStatsSource interface {
+	MatchStats(ctx context.Context, matchLinks []string) ([]Match, []error, error)
+}
+
+// jobResource is implemented by sources that hold something for the length of
+// a scrape job, such as a browser.
+type jobResource interface {
+	Start() error
+	Close()
+}
+
+// jobResources returns the distinct match sources that need starting before a
+// job.
+func (s *Server) jobResources() []jobResource {
+	var resources []jobResource
+	for _, source := range []interface{}{s.matchLinks, s.matchStats} {
+		resource, ok := source.(jobResource)
+		if !ok || slices.Contains(resources, resource) {
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// FixtureSource serves match links and scoreboards saved as JSON, so the
+// pipeline can run without a browser. Dir is laid out as
+//
+//	profiles/<steamID>.json  array of match page links, or of profileLinks
+//	matches/<matchID>.json   a ScrapedMatchData
+//
+// where the IDs are the last path segment of the profile and match URLs.
+// Missing files are logged and skipped, like failed page loads. The zero
+// MatchLinkLimits serve every link.
+type FixtureSource struct {
+	Dir string
+	MatchLinkLimits
+}
+
+func (f *FixtureSource) MatchLinks(ctx context.Context, profileURLs []string) ([]MatchLink, error) {
+	var matchLinks []MatchLink
+	for _, profileURL := range profileURLs {
+		var links []profileLink
+		file := filepath.Join(f.Dir, "profiles", path.Base(profileURL)+".json")
+		if err := readFixture(file, &links); err != nil {
+			log.Println("Error: ", err)
+			continue
+		}
+		matchLinks = append(matchLinks, profileMatchLinks(profileURL, f.apply(links))...)
+	}
+	return matchLinks, nil
+}
+
+func (f *FixtureSource) MatchStats(ctx context.Context, matchLinks []string) ([]Match, []error, error) {
+	var scraped []ScrapedMatchData
+	for _, matchLink := range matchLinks {
+		var data ScrapedMatchData
+		file := filepath.Join(f.Dir, "matches", path.Base(matchLink)+".json")
+		if err := readFixture(file, &data); err != nil {
+			log.Println("Error: ", err)
+			continue
+		}
+		data.URL = matchLink
+		scraped = append(scraped, data)
+	}
+	matches, parseErrs := parseScrapedMatches(scraped)
+	return matches, parseErrs, nil
+}
+
+func readFixture(file string, v interface{}) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScrapeAndSaveMatchesFromFixtures(t *testing.T) {
+	s := newTestServer(t)
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+
+	saved, err := s.scrapeAndSaveMatches(context.Background(), "EU", []string{
+		leetifyUserURL + "76561198000000001",
+		leetifyUserURL + "76561198000000002",
+	})
+	if err != nil {
+		t.Fatalf("error scraping fixtures. Err: %v", err)
+	}
+	// missing-1 has no fixture, short-1 has four players on one side and bad-1
+	// has an unparseable K/D and no utility values for its losers
+	if saved != 2 {
+		t.Fatalf("expected 2 matches saved; got %d", saved)
+	}
+	if errs := strings.Join(s.scrape.snapshot().Errors, "\n"); strings.Count(errs, "\n") != 2 || !strings.Contains(errs, "short-1") || !strings.Contains(errs, "no player has a utility value") {
+		t.Errorf("expected parse errors for short-1 and bad-1; got %v", errs)
+	}
+
+	var url string
+	var wKD, lADR float64
+	err = s.dbConn.QueryRow(`SELECT match_url, w_avg_kd, l_avg_adr FROM matches WHERE NOT tie`).Scan(&url, &wKD, &lADR)
+	if err != nil {
+		t.Fatalf("error reading matches. Err: %v", err)
+	}
+	if url != "https://leetify.com/app/match-details/win-1" || wKD != 1.3 || lADR != 70 {
+		t.Errorf("unexpected match %s: w_avg_kd %v, l_avg_adr %v", url, wKD, lADR)
+	}
+
+	var players, withSteamID int
+	err = s.dbConn.QueryRow(`SELECT COUNT(*), COUNT(steam_id) FROM match_players WHERE match_url = ?`, url).Scan(&players, &withSteamID)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if players != 10 || withSteamID != 10 {
+		t.Errorf("expected 10 player lines with steam IDs; got %d lines, %d steam IDs", players, withSteamID)
+	}
+
+	var mapName, dataSource string
+	var wRounds, lRounds int
+	var playedAt time.Time
+	err = s.dbConn.QueryRow(`SELECT map, w_rounds, l_rounds, played_at, data_source FROM matches WHERE match_url = ?`, url).Scan(&mapName, &wRounds, &lRounds, &playedAt, &dataSource)
+	if err != nil {
+		t.Fatalf("error reading match details. Err: %v", err)
+	}
+	if mapName != "de_dust2" || wRounds != 13 || lRounds != 9 || dataSource != "faceit" {
+		t.Errorf("unexpected match details %s %d-%d %s", mapName, wRounds, lRounds, dataSource)
+	}
+	if !playedAt.Equal(time.Date(2025, 7, 14, 20, 31, 0, 0, time.UTC)) {
+		t.Errorf("unexpected played_at %s", playedAt)
+	}
+
+	var tieWKD, tieLKD float64
+	var tieWins int
+	err = s.dbConn.QueryRow(`SELECT w_avg_kd, l_avg_kd FROM matches WHERE match_url = ? AND tie`, "https://leetify.com/app/match-details/tie-1").Scan(&tieWKD, &tieLKD)
+	if err != nil {
+		t.Fatalf("error reading tied match. Err: %v", err)
+	}
+	if tieWKD != 1.3 || tieLKD != 0.8 {
+		t.Errorf("expected averages of both tied teams; got w_avg_kd %v, l_avg_kd %v", tieWKD, tieLKD)
+	}
+	err = s.dbConn.QueryRow(`SELECT COUNT(*) FROM match_players WHERE match_url = ? AND won`, "https://leetify.com/app/match-details/tie-1").Scan(&tieWins)
+	if err != nil {
+		t.Fatalf("error reading tied match players. Err: %v", err)
+	}
+	if tieWins != 0 {
+		t.Errorf("expected no winners in a tie; got %d", tieWins)
+	}
+
+	var links int
+	err = s.dbConn.QueryRow(`SELECT COUNT(*) FROM player_matches WHERE match_url = ?`, url).Scan(&links)
+	if err != nil {
+		t.Fatalf("error reading player_matches. Err: %v", err)
+	}
+	if links != 2 {
+		t.Errorf("expected win-1 linked to both profiles that surfaced it; got %d links", links)
+	}
+}
+
+func TestJobResourcesDeduplicates(t *testing.T) {
+	scraper := NewChromedpScraper()
+	s := &Server{matchLinks: scraper, matchStats: scraper}
+	if n := len(s.jobResources()); n != 1 {
+		t.Errorf("expected 1 job resource; got %d", n)
+	}
+
+	fixtures := &FixtureSource{}
+	s = &Server{matchLinks: fixtures, matchStats: fixtures}
+	if n := len(s.jobResources()); n != 0 {
+		t.Errorf("expected no job resources for fixtures; got %d", n)
+	}
+}
+
+func TestFixtureMatchLinkLimits(t *testing.T) {
+	profiles := []string{leetifyUserURL + "76561198000000001", leetifyUserURL + "76561198000000002"}
+	since := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		limits MatchLinkLimits
+		want   []string // match IDs of the second profile
+		total  int
+	}{
+		{MatchLinkLimits{}, []string{"win-1", "bad-1", "short-1"}, 6},
+		{MatchLinkLimits{Depth: 1}, []string{"win-1"}, 2},
+		// the first profile's links have no dates, so the cutoff keeps them
+		{MatchLinkLimits{Since: since}, []string{"win-1", "bad-1"}, 5},
+		{MatchLinkLimits{Depth: 1, Since: since}, []string{"win-1"}, 2},
+	}
+	for _, tt := range tests {
+		fixtures := &FixtureSource{Dir: "testdata/leetify", MatchLinkLimits: tt.limits}
+		links, err := fixtures.MatchLinks(context.Background(), profiles)
+		if err != nil {
+			t.Fatalf("error reading match links. Err: %v", err)
+		}
+		var got []string
+		for _, link := range links {
+			if link.ProfileURL == profiles[1] {
+				got = append(got, path.Base(link.MatchURL))
+			}
+		}
+		if len(links) != tt.total || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%+v: expected %d links, %v from the second profile; got %d, %v", tt.limits, tt.total, tt.want, len(links), got)
+		}
+	}
+}
+
+func TestMatchLinkLimitsApply(t *testing.T) {
+	links := []profileLink{
+		{URL: "a", PlayedAt: "2025-07-14T20:31:00Z"},
+		{URL: "a", PlayedAt: "2025-07-14T20:31:00Z"},
+		{URL: "b"},
+		{URL: "c", PlayedAt: "2025-06-01T10:00:00Z"},
+		{URL: "d", PlayedAt: "2025-07-10T10:00:00Z"},
+	}
+	since := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		limits MatchLinkLimits
+		want   string
+	}{
+		{MatchLinkLimits{}, "a,b,c,d"},
+		{MatchLinkLimits{Depth: 2}, "a,b"},
+		{MatchLinkLimits{Since: since}, "a,b,d"},
+		{MatchLinkLimits{Depth: 3, Since: since}, "a,b,d"},
+	} {
+		if got := strings.Join(tt.limits.apply(links), ","); got != tt.want {
+			t.Errorf("%+v: expected %s; got %s", tt.limits, tt.want, got)
+		}
+	}
+}
+
+func TestParseMatchLinkLimits(t *testing.T) {
+	limits, err := parseMatchLinkLimits("", "")
+	if err != nil || limits.Depth != defaultMatchDepth || !limits.Since.IsZero() {
+		t.Errorf("expected the default depth; got %+v, %v", limits, err)
+	}
+	limits, err = parseMatchLinkLimits("", "2025-06-01")
+	if err != nil || limits.Depth != 0 || !limits.Since.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected every match since 2025-06-01; got %+v, %v", limits, err)
+	}
+	limits, err = parseMatchLinkLimits("30", "2025-06-01T12:00:00+02:00")
+	if err != nil || limits.Depth != 30 || !limits.Since.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 30 matches since 10:00 UTC; got %+v, %v", limits, err)
+	}
+
+	for _, tc := range [][2]string{{"-1", ""}, {"many", ""}, {"0", ""}, {"", "June"}} {
+		if _, err := parseMatchLinkLimits(tc[0], tc[1]); err == nil {
+			t.Errorf("expected error for depth %q, since %q", tc[0], tc[1])
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
@@ -11,9 +13,39 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	// Register routes
 	mux.HandleFunc("/", s.HelloWorldHandler)
+	mux.HandleFunc("GET /api/matches", s.ListMatchesHandler)
+	mux.HandleFunc("GET /api/players", s.ListPlayersHandler)
+	mux.HandleFunc("GET /api/players/{steamID}", s.GetPlayerHandler)
+	mux.HandleFunc("GET /api/players/{steamID}/elo", s.GetPlayerEloHandler)
+	mux.HandleFunc("GET /api/players/{steamID}/matches", s.ListPlayerMatchesHandler)
+	mux.HandleFunc("GET /api/players/{steamID}/faceit-matches", s.ListPlayerFaceitMatchesHandler)
+	mux.HandleFunc("GET /api/insights/differentials", s.DifferentialsHandler)
+
+	// Admin routes need the admin token and aren't shared with other origins
+	admin := http.NewServeMux()
+	admin.HandleFunc("POST /admin/scrape", s.StartScrapeHandler)
+	admin.HandleFunc("GET /admin/scrape/status", s.ScrapeStatusHandler)
+	admin.HandleFunc("DELETE /admin/scrape", s.CancelScrapeHandler)
+	admin.HandleFunc("GET /admin/scrape/errors", s.ListScrapeErrorsHandler)
 
 	// Wrap the mux with CORS middleware
-	return s.corsMiddleware(mux)
+	root := http.NewServeMux()
+	root.Handle("/admin/", s.adminMiddleware(admin))
+	root.Handle("/", s.corsMiddleware(mux))
+	return root
+}
+
+// adminMiddleware only lets through requests carrying the ADMIN_TOKEN as a
+// bearer token. With no token configured every request is refused.
+func (s *Server) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			respondWithError(w, http.StatusUnauthorized, "unauthorized", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	scrapeRunSucceeded = "succeeded"
+	scrapeRunFailed    = "failed"
+)
+
+var errScrapeRunning = errors.New("scrape job already running")
+
+// parseSchedule builds the scrape schedule from SCRAPE_INTERVAL (a Go
+// duration such as "6h") or SCRAPE_CRON (a standard five field cron
+// expression). Setting neither returns a nil schedule, meaning the job only
+// runs once at boot.
+func parseSchedule(interval, cronExpr string) (cron.Schedule, error) {
+	switch {
+	case interval != "" && cronExpr != "":
+		return nil, errors.New("set only one of SCRAPE_INTERVAL and SCRAPE_CRON")
+	case interval != "":
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPE_INTERVAL: %w", err)
+		}
+		if d < time.Minute {
+			return nil, errors.New("SCRAPE_INTERVAL must be at least 1m")
+		}
+		return cron.Every(d), nil
+	case cronExpr != "":
+		schedule, err := cron.ParseStandard(cronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPE_CRON: %w", err)
+		}
+		return schedule, nil
+	}
+	return nil, nil
+}
+
+// runScheduler runs the scrape job once at boot and then whenever schedule
+// fires, until ctx is cancelled.
+func (s *Server) runScheduler(ctx context.Context, schedule cron.Schedule) {
+	for {
+		if err := s.runScrapeJob(ctx); err != nil {
+			log.Printf("error: %s", err)
+		}
+		if schedule == nil {
+			return
+		}
+
+		next := schedule.Next(time.Now())
+		log.Printf("Next scrape run at %s", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// ScrapeStatus is the progress of the current, or most recent, scrape job.
+type ScrapeStatus struct {
+	Running         bool       `json:"running"`
+	RunID           int64      `json:"run_id,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	Region          string     `json:"region"`
+	WindowStart     int        `json:"window_start"`
+	WindowEnd       int        `json:"window_end"`
+	PlayersFetched  int        `json:"players_fetched"`
+	MatchLinksFound int        `json:"match_links_found"`
+	MatchesSaved    int        `json:"matches_saved"`
+	Errors          []string   `json:"errors"`
+}
+
+// scrapeJob tracks the running scrape job so runs never overlap and can be
+// inspected or cancelled from the admin API.
+type scrapeJob struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc // non-nil while a job runs
+	status ScrapeStatus
+}
+
+// start claims the job slot and resets progress. It returns false if a job is
+// already running.
+func (j *scrapeJob) start(parent context.Context) (context.Context, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(parent)
+	j.cancel = cancel
+	now := time.Now().UTC()
+	j.status = ScrapeStatus{
+		Running:   true,
+		StartedAt: &now,
+		Errors:    []string{},
+	}
+	return ctx, true
+}
+
+// finish releases the job slot.
+func (j *scrapeJob) finish() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel == nil {
+		return
+	}
+	j.cancel()
+	j.cancel = nil
+	now := time.Now().UTC()
+	j.status.Running = false
+	j.status.FinishedAt = &now
+}
+
+// stop cancels the running job. It returns false if no job is running.
+func (j *scrapeJob) stop() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel == nil {
+		return false
+	}
+	j.cancel()
+	return true
+}
+
+// startedAt returns when the running job started, or now if none is running,
+// so every snapshot taken during one run shares a timestamp.
+func (j *scrapeJob) startedAt() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel != nil && j.status.StartedAt != nil {
+		return *j.status.StartedAt
+	}
+	return time.Now().UTC()
+}
+
+func (j *scrapeJob) update(fn func(status *ScrapeStatus)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.status)
+}
+
+func (j *scrapeJob) addError(err error) {
+	j.update(func(status *ScrapeStatus) {
+		status.Errors = append(status.Errors, err.Error())
+	})
+}
+
+func (j *scrapeJob) snapshot() ScrapeStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	status.Errors = slices.Clone(j.status.Errors)
+	if status.Errors == nil {
+		status.Errors = []string{}
+	}
+	return status
+}
+
+// runScrapeJob runs FetchAndScrapeJob and records the run in scrape_runs. It
+// returns errScrapeRunning instead of starting a second job while one is in
+// progress.
+func (s *Server) runScrapeJob(ctx context.Context) error {
+	ctx, ok := s.scrape.start(ctx)
+	if !ok {
+		return errScrapeRunning
+	}
+	return s.runStartedScrapeJob(ctx)
+}
+
+// runStartedScrapeJob runs a job whose slot was already claimed with
+// s.scrape.start, releasing the slot when done.
+func (s *Server) runStartedScrapeJob(ctx context.Context) error {
+	defer s.scrape.finish()
+
+	run, err := s.db.CreateScrapeRun(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to record scrape run: %w", err)
+	}
+	s.scrape.update(func(status *ScrapeStatus) {
+		status.RunID = run.ID
+	})
+
+	jobErr := s.FetchAndScrapeJob(ctx)
+
+	params := database.FinishScrapeRunParams{
+		Status: scrapeRunSucceeded,
+		ID:     run.ID,
+	}
+	if jobErr != nil {
+		params.Status = scrapeRunFailed
+		params.ErrorMessage = sql.NullString{String: jobErr.Error(), Valid: true}
+	}
+	// record the outcome even if ctx was what stopped the job
+	if err := s.db.FinishScrapeRun(context.Background(), params); err != nil {
+		log.Printf("error: failed to finish scrape run %d: %s", run.ID, err)
+	}
+
+	return jobErr
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := parseSchedule("", "")
+	if err != nil || schedule != nil {
+		t.Errorf("expected no schedule when unset; got %v, %v", schedule, err)
+	}
+
+	schedule, err = parseSchedule("6h", "")
+	if err != nil {
+		t.Fatalf("error parsing interval. Err: %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if next := schedule.Next(now); next != now.Add(6*time.Hour) {
+		t.Errorf("expected next run 6h later; got %s", next)
+	}
+
+	schedule, err = parseSchedule("", "30 3 * * *")
+	if err != nil {
+		t.Fatalf("error parsing cron. Err: %v", err)
+	}
+	if next := schedule.Next(now); next != now.Add(3*time.Hour+30*time.Minute) {
+		t.Errorf("expected next run at 03:30; got %s", next)
+	}
+
+	for _, tc := range [][2]string{{"6h", "0 3 * * *"}, {"soon", ""}, {"10s", ""}, {"", "every night"}} {
+		if _, err := parseSchedule(tc[0], tc[1]); err == nil {
+			t.Errorf("expected error for interval %q, cron %q", tc[0], tc[1])
+		}
+	}
+}
+
+func TestRunScrapeJobRecordsRun(t *testing.T) {
+	s := newTestServer(t)
+	s.faceit = newFaceitStub(t, stubLeaderboard(60))
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+
+	// the deadline expires during the pause between leaderboard pages
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.runScrapeJob(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected job to stop at deadline; got %v", err)
+	}
+
+	runs, err := s.db.ListScrapeRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("error listing scrape runs. Err: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 scrape run; got %d", len(runs))
+	}
+	if runs[0].Status != scrapeRunFailed || !runs[0].FinishedAt.Valid || !runs[0].ErrorMessage.Valid {
+		t.Errorf("expected finished failed run with error; got %+v", runs[0])
+	}
+}
+
+// cancelingLinks cancels the job as soon as it asks for match links.
+type cancelingLinks struct {
+	*FixtureSource
+	cancel context.CancelFunc
+}
+
+func (c cancelingLinks) MatchLinks(ctx context.Context, profileURLs []string) ([]MatchLink, error) {
+	c.cancel()
+	return c.FixtureSource.MatchLinks(ctx, profileURLs)
+}
+
+func TestRunScrapeJobCancelledInLastWindow(t *testing.T) {
+	s := newTestServer(t)
+	s.faceit = newFaceitStub(t, map[string]PlayerDetails{
+		"faceit-1": {PlayerID: "faceit-1", Nickname: "one", SteamID64: "76561198000000001"},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = cancelingLinks{fixtures, cancel}
+	s.matchStats = fixtures
+
+	// the only window is the end of the leaderboard
+	if err := s.runScrapeJob(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled job to fail; got %v", err)
+	}
+	runs, err := s.db.ListScrapeRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("error listing scrape runs. Err: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != scrapeRunFailed {
+		t.Errorf("expected 1 failed scrape run; got %+v", runs)
+	}
+}
+
+func TestRunScrapeJobFailsWhenNoWindowSucceeds(t *testing.T) {
+	s := newTestServer(t)
+	rankings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(rankings.Close)
+	s.faceit = NewFaceitClient("test-key")
+	s.faceit.BaseURL = rankings.URL
+	s.faceit.Limiter = rate.NewLimiter(rate.Inf, 1)
+	fixtures := &FixtureSource{Dir: "testdata/leetify"}
+	s.matchLinks = fixtures
+	s.matchStats = fixtures
+
+	pause := leaderboardPause
+	leaderboardPause = 0
+	t.Cleanup(func() { leaderboardPause = pause })
+
+	if err := s.runScrapeJob(context.Background()); err == nil {
+		t.Fatal("expected the job to fail when every window failed")
+	}
+	runs, err := s.db.ListScrapeRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("error listing scrape runs. Err: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != scrapeRunFailed {
+		t.Errorf("expected 1 failed scrape run; got %+v", runs)
+	}
+}
+
+func TestRunScrapeJobNoOverlap(t *testing.T) {
+	s := newTestServer(t)
+
+	if _, ok := s.scrape.start(context.Background()); !ok {
+		t.Fatal("expected to claim the job slot")
+	}
+	defer s.scrape.finish()
+	if err := s.runScrapeJob(context.Background()); !errors.Is(err, errScrapeRunning) {
+		t.Fatalf("expected errScrapeRunning; got %v", err)
+	}
+
+	runs, err := s.db.ListScrapeRuns(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("error listing scrape runs. Err: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("expected no scrape run recorded; got %d", len(runs))
+	}
+}
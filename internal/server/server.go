@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"cs2-stat/internal/database"
 	"database/sql"
 	"fmt"
@@ -15,10 +16,23 @@ import (
 )
 
 type Server struct {
-	port         int
-	db           *database.Queries
-	dbConn       *sql.DB
-	faceitApiKey string
+	port       int
+	db         *database.Queries
+	dbConn     *sql.DB
+	faceit     *FaceitClient
+	matchLinks MatchLinkSource
+	matchStats MatchStatsSource
+	regions    []string
+
+	// adminToken is the bearer token the /admin routes require; they're
+	// closed when it's empty
+	adminToken string
+
+	// matchHistory is the number of recent Faceit matches pulled per player,
+	// 0 to skip the Faceit match stats
+	matchHistory int
+
+	scrape scrapeJob
 }
 
 func NewServer() *http.Server {
@@ -32,21 +46,78 @@ func NewServer() *http.Server {
 		log.Fatal("FACEIT_API_KEY MUST BE SET")
 	}
 
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		log.Print("ADMIN_TOKEN is not set, the admin routes are disabled")
+	}
+
+	regions, err := parseRegions(os.Getenv("FACEIT_REGIONS"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
+	schedule, err := parseSchedule(os.Getenv("SCRAPE_INTERVAL"), os.Getenv("SCRAPE_CRON"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
+	faceitLimiter, err := parseRateLimit(os.Getenv("FACEIT_RATE_LIMIT"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
+	matchHistory, err := parseMatchHistory(os.Getenv("FACEIT_MATCH_HISTORY"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
+	matchLinkLimits, err := parseMatchLinkLimits(os.Getenv("LEETIFY_MATCH_DEPTH"), os.Getenv("LEETIFY_MATCHES_SINCE"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
+	maxLoadMore, err := parseMaxLoadMore(os.Getenv("LEETIFY_MAX_LOAD_MORE"))
+	if err != nil {
+		log.Fatalf("fatal: %s", err)
+	}
+
 	dbConn, err := sql.Open("sqlite3", dbUrl)
 	if err != nil {
 		log.Fatalf("fatal: %s", err)
 	}
 	db := database.New(dbConn)
 
+	skipMigrations, _ := strconv.ParseBool(os.Getenv("SKIP_MIGRATIONS"))
+	if !skipMigrations {
+		if err := database.Migrate(context.Background(), dbConn, "up"); err != nil {
+			log.Fatalf("fatal: failed to apply migrations: %s", err)
+		}
+	}
+
+	faceit := NewFaceitClient(faceitApiKey)
+	faceit.Limiter = faceitLimiter
+	if faceitBaseURL := os.Getenv("FACEIT_BASE_URL"); faceitBaseURL != "" {
+		faceit.BaseURL = faceitBaseURL
+	}
+
+	scraper := NewChromedpScraper()
+	scraper.MatchLinkLimits = matchLinkLimits
+	scraper.MaxLoadMore = maxLoadMore
 	NewServer := &Server{
-		port:         port,
-		db:           db,
-		dbConn:       dbConn,
-		faceitApiKey: faceitApiKey,
+		port:       port,
+		db:         db,
+		dbConn:     dbConn,
+		faceit:     faceit,
+		matchLinks: scraper,
+		matchStats: scraper,
+		regions:    regions,
+		adminToken: adminToken,
+
+		matchHistory: matchHistory,
 	}
 	log.Print("connected to db")
 
-	go NewServer.StartFetchAndScrape()
+	go NewServer.runScheduler(context.Background(), schedule)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
@@ -57,10 +128,3 @@ func NewServer() *http.Server {
 	}
 	return server
 }
-
-func (s *Server) StartFetchAndScrape() {
-	err := s.FetchAndScrapeJob()
-	if err != nil {
-		log.Printf("error: %s", err)
-	}
-}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"cs2-stat/internal/database"
+	"database/sql"
+	"testing"
+)
+
+// testAdminToken is the ADMIN_TOKEN of servers from newTestServer.
+const testAdminToken = "test-admin-token"
+
+// newTestServer returns a Server backed by an in-memory database with every
+// embedded migration applied.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("error opening database. Err: %v", err)
+	}
+	// every connection to :memory: is a new database
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { dbConn.Close() })
+
+	if err := database.Migrate(context.Background(), dbConn, "up"); err != nil {
+		t.Fatalf("error applying migrations. Err: %v", err)
+	}
+
+	return &Server{
+		db:         database.New(dbConn),
+		dbConn:     dbConn,
+		adminToken: testAdminToken,
+	}
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	s := newTestServer(t)
+	insertTestPlayers(t, s)
+
+	for _, command := range []string{"reset", "up"} {
+		if err := database.Migrate(context.Background(), s.dbConn, command); err != nil {
+			t.Fatalf("error running migrate %s. Err: %v", command, err)
+		}
+	}
+	if _, err := s.db.ListPlayers(context.Background(), database.ListPlayersParams{RowLimit: 1}); err != nil {
+		t.Errorf("expected players table after re-applying migrations. Err: %v", err)
+	}
+}
+
+func TestMatchPlayersKeysMigrationKeepsLatest(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("error opening database. Err: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+	t.Cleanup(func() { dbConn.Close() })
+
+	if err := database.Migrate(context.Background(), dbConn, "up-to", "14"); err != nil {
+		t.Fatalf("error applying migrations. Err: %v", err)
+	}
+	// keyed on the name before 015, so a renamed player was saved twice
+	_, err = dbConn.Exec(`INSERT INTO match_players (match_url, player_name, steam_id, team, won, adr, created_at, updated_at)
+		VALUES ('m', 'oldname', '76561198000000001', 0, TRUE, 80, '2025-07-01 10:00:00', '2025-07-01 10:00:00'),
+			('m', 'newname', '76561198000000001', 0, TRUE, 95, '2025-07-01 10:00:00', '2025-07-02 10:00:00')`)
+	if err != nil {
+		t.Fatalf("error seeding match_players. Err: %v", err)
+	}
+
+	if err := database.Migrate(context.Background(), dbConn, "up"); err != nil {
+		t.Fatalf("error applying migrations. Err: %v", err)
+	}
+
+	var count int
+	var name string
+	err = dbConn.QueryRow(`SELECT COUNT(*), MAX(player_name) FROM match_players WHERE match_url = 'm'`).Scan(&count, &name)
+	if err != nil {
+		t.Fatalf("error reading match_players. Err: %v", err)
+	}
+	if count != 1 || name != "newname" {
+		t.Errorf("expected only the latest line kept; got %d rows, %q", count, name)
+	}
+}
@@ -0,0 +1,40 @@
+-- name: CreateFaceitMatchPlayer :exec
+INSERT INTO faceit_match_players (
+  match_id,
+  player_id,
+  steam_id,
+  nickname,
+  team,
+  won,
+  map,
+  kills,
+  deaths,
+  assists,
+  kd,
+  adr,
+  headshots_pct,
+  finished_at,
+  created_at,
+  updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT(match_id, player_id) DO UPDATE SET
+  steam_id = COALESCE(excluded.steam_id, faceit_match_players.steam_id),
+  nickname = excluded.nickname,
+  team = excluded.team,
+  won = excluded.won,
+  map = excluded.map,
+  kills = excluded.kills,
+  deaths = excluded.deaths,
+  assists = excluded.assists,
+  kd = excluded.kd,
+  adr = excluded.adr,
+  headshots_pct = excluded.headshots_pct,
+  finished_at = excluded.finished_at,
+  updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListFaceitPlayerMatches :many
+SELECT match_id, player_id, steam_id, nickname, team, won, map, kills, deaths, assists, kd, adr, headshots_pct, finished_at, created_at, updated_at
+FROM faceit_match_players
+WHERE steam_id = sqlc.arg(steam_id)
+ORDER BY finished_at DESC, match_id DESC
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
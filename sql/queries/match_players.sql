@@ -0,0 +1,54 @@
+-- name: CreateMatchPlayer :exec
+INSERT INTO match_players (
+  match_url,
+  player_name,
+  steam_id,
+  team,
+  won,
+  tie,
+  leetify_rating,
+  personal_performance,
+  hltv_rating,
+  kd,
+  adr,
+  aim,
+  utility,
+  created_at,
+  updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT(match_url, steam_id) WHERE steam_id IS NOT NULL DO UPDATE SET
+  player_name = excluded.player_name,
+  team = excluded.team,
+  won = excluded.won,
+  tie = excluded.tie,
+  leetify_rating = excluded.leetify_rating,
+  personal_performance = excluded.personal_performance,
+  hltv_rating = excluded.hltv_rating,
+  kd = excluded.kd,
+  adr = excluded.adr,
+  aim = excluded.aim,
+  utility = excluded.utility,
+  updated_at = CURRENT_TIMESTAMP
+ON CONFLICT(match_url, team, player_name) WHERE steam_id IS NULL DO UPDATE SET
+  team = excluded.team,
+  won = excluded.won,
+  tie = excluded.tie,
+  leetify_rating = excluded.leetify_rating,
+  personal_performance = excluded.personal_performance,
+  hltv_rating = excluded.hltv_rating,
+  kd = excluded.kd,
+  adr = excluded.adr,
+  aim = excluded.aim,
+  utility = excluded.utility,
+  updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteUnlinkedMatchPlayer :exec
+DELETE FROM match_players
+WHERE match_url = ? AND team = ? AND player_name = ? AND steam_id IS NULL;
+
+-- name: ListPlayerMatchStats :many
+SELECT match_url, player_name, steam_id, team, won, leetify_rating, personal_performance, hltv_rating, kd, adr, aim, utility, created_at, updated_at, tie
+FROM match_players
+WHERE steam_id = sqlc.arg(steam_id)
+ORDER BY created_at DESC, match_url DESC
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
@@ -7,15 +7,24 @@ INSERT INTO matches (
   w_avg_kd,
   w_avg_aim,
   w_avg_utility,
+  w_avg_adr,
   l_avg_leetify_rating,
   l_avg_personal_performance,
   l_avg_hltv_rating,
   l_avg_kd,
   l_avg_aim,
   l_avg_utility,
+  l_avg_adr,
+  region,
+  map,
+  w_rounds,
+  l_rounds,
+  played_at,
+  data_source,
+  tie,
   created_at,
   updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON CONFLICT(match_url) DO UPDATE SET
   match_url = excluded.match_url,
   w_avg_leetify_rating = excluded.w_avg_leetify_rating,
@@ -24,10 +33,87 @@ ON CONFLICT(match_url) DO UPDATE SET
   w_avg_kd = excluded.w_avg_kd,
   w_avg_aim = excluded.w_avg_aim,
   w_avg_utility = excluded.w_avg_utility,
+  w_avg_adr = excluded.w_avg_adr,
   l_avg_leetify_rating = excluded.l_avg_leetify_rating,
   l_avg_personal_performance = excluded.l_avg_personal_performance,
   l_avg_hltv_rating = excluded.l_avg_hltv_rating,
   l_avg_kd = excluded.l_avg_kd,
   l_avg_aim = excluded.l_avg_aim,
   l_avg_utility = excluded.l_avg_utility,
-  updated_at = CURRENT_TIMESTAMP;
\ No newline at end of file
+  l_avg_adr = excluded.l_avg_adr,
+  region = excluded.region,
+  map = COALESCE(excluded.map, matches.map),
+  w_rounds = COALESCE(excluded.w_rounds, matches.w_rounds),
+  l_rounds = COALESCE(excluded.l_rounds, matches.l_rounds),
+  played_at = COALESCE(excluded.played_at, matches.played_at),
+  data_source = COALESCE(excluded.data_source, matches.data_source),
+  tie = excluded.tie,
+  updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListMatches :many
+SELECT sqlc.embed(m), s.sort_value
+FROM (
+  SELECT
+    match_url,
+    CAST(CASE CAST(sqlc.arg(sort_by) AS TEXT)
+      WHEN 'w_avg_leetify_rating' THEN w_avg_leetify_rating
+      WHEN 'w_avg_personal_performance' THEN w_avg_personal_performance
+      WHEN 'w_avg_hltv_rating' THEN w_avg_hltv_rating
+      WHEN 'w_avg_kd' THEN w_avg_kd
+      WHEN 'w_avg_aim' THEN w_avg_aim
+      WHEN 'w_avg_utility' THEN w_avg_utility
+      WHEN 'w_avg_adr' THEN w_avg_adr
+      WHEN 'l_avg_leetify_rating' THEN l_avg_leetify_rating
+      WHEN 'l_avg_personal_performance' THEN l_avg_personal_performance
+      WHEN 'l_avg_hltv_rating' THEN l_avg_hltv_rating
+      WHEN 'l_avg_kd' THEN l_avg_kd
+      WHEN 'l_avg_aim' THEN l_avg_aim
+      WHEN 'l_avg_utility' THEN l_avg_utility
+      WHEN 'l_avg_adr' THEN l_avg_adr
+      WHEN 'played_at' THEN julianday(played_at)
+      ELSE julianday(created_at)
+    END AS REAL) AS sort_value
+  FROM matches
+  WHERE (sqlc.narg(created_after) IS NULL OR created_at >= sqlc.narg(created_after))
+    AND (sqlc.narg(created_before) IS NULL OR created_at < sqlc.narg(created_before))
+    AND (sqlc.narg(region) IS NULL OR region = sqlc.narg(region))
+    AND (sqlc.narg(map) IS NULL OR map = sqlc.narg(map) COLLATE NOCASE)
+    AND (sqlc.narg(played_after) IS NULL OR played_at >= sqlc.narg(played_after))
+    AND (sqlc.narg(played_before) IS NULL OR played_at < sqlc.narg(played_before))
+    AND (sqlc.narg(tie) IS NULL OR tie = sqlc.narg(tie))
+) s
+JOIN matches m ON m.match_url = s.match_url
+WHERE s.sort_value IS NOT NULL
+  AND (sqlc.narg(cursor_value) IS NULL
+    OR (CAST(sqlc.arg(sort_desc) AS BOOLEAN) AND (s.sort_value < sqlc.narg(cursor_value) OR (s.sort_value = sqlc.narg(cursor_value) AND s.match_url < sqlc.arg(cursor_url))))
+    OR (NOT CAST(sqlc.arg(sort_desc) AS BOOLEAN) AND (s.sort_value > sqlc.narg(cursor_value) OR (s.sort_value = sqlc.narg(cursor_value) AND s.match_url > sqlc.arg(cursor_url)))))
+ORDER BY
+  CASE WHEN CAST(sqlc.arg(sort_desc) AS BOOLEAN) THEN s.sort_value END DESC,
+  CASE WHEN CAST(sqlc.arg(sort_desc) AS BOOLEAN) THEN s.match_url END DESC,
+  s.sort_value ASC,
+  s.match_url ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListMatchDifferentials :many
+-- One row per decided match and metric, keyed by the match_players column
+-- name. adr goes first so delta is nullable, matches saved before ADR was
+-- recorded have none.
+WITH decided AS (
+  SELECT * FROM matches
+  WHERE NOT tie
+    AND (sqlc.narg(region) IS NULL OR region = sqlc.narg(region))
+    AND (sqlc.narg(map) IS NULL OR map = sqlc.narg(map) COLLATE NOCASE)
+)
+SELECT match_url, CAST('adr' AS TEXT) AS metric, CAST(w_avg_adr - l_avg_adr AS REAL) AS delta FROM decided
+UNION ALL
+SELECT match_url, 'leetify_rating', CAST(w_avg_leetify_rating - l_avg_leetify_rating AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'personal_performance', CAST(w_avg_personal_performance - l_avg_personal_performance AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'hltv_rating', CAST(w_avg_hltv_rating - l_avg_hltv_rating AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'kd', CAST(w_avg_kd - l_avg_kd AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'aim', CAST(w_avg_aim - l_avg_aim AS REAL) FROM decided
+UNION ALL
+SELECT match_url, 'utility', CAST(w_avg_utility - l_avg_utility AS REAL) FROM decided;
@@ -0,0 +1,15 @@
+-- name: CreatePlayerMatch :exec
+INSERT INTO player_matches (steam_id, match_url, created_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(steam_id, match_url) DO NOTHING;
+
+-- name: ListPlayerMatches :many
+SELECT sqlc.embed(m), CAST(julianday(m.created_at) AS REAL) AS sort_value
+FROM player_matches pm
+JOIN matches m ON m.match_url = pm.match_url
+WHERE pm.steam_id = sqlc.arg(steam_id)
+  AND (sqlc.narg(cursor_value) IS NULL
+    OR julianday(m.created_at) < sqlc.narg(cursor_value)
+    OR (julianday(m.created_at) = sqlc.narg(cursor_value) AND m.match_url < sqlc.arg(cursor_url)))
+ORDER BY julianday(m.created_at) DESC, m.match_url DESC
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- name: CreatePlayerSnapshot :exec
+INSERT INTO player_snapshots (steam_id, captured_at, region, faceit_elo, skill_level, leaderboard_position)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(steam_id, captured_at) DO UPDATE SET
+  region = excluded.region,
+  faceit_elo = excluded.faceit_elo,
+  skill_level = excluded.skill_level,
+  leaderboard_position = excluded.leaderboard_position;
+
+-- name: ListPlayerSnapshots :many
+SELECT steam_id, captured_at, region, faceit_elo, skill_level, leaderboard_position
+FROM player_snapshots
+WHERE steam_id = sqlc.arg(steam_id)
+  AND (sqlc.narg(captured_after) IS NULL OR captured_at >= sqlc.narg(captured_after))
+  AND (sqlc.narg(captured_before) IS NULL OR captured_at < sqlc.narg(captured_before))
+ORDER BY captured_at;
\ No newline at end of file
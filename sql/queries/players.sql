@@ -1,10 +1,29 @@
 -- name: CreatePlayer :one
-INSERT INTO players (steam_id, name, country, faceit_url, avatar, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO players (steam_id, name, country, faceit_url, avatar, region, faceit_elo, leaderboard_position, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 ON CONFLICT(steam_id) DO UPDATE SET
   name = excluded.name,
   country = excluded.country,
   faceit_url = excluded.faceit_url,
   avatar = excluded.avatar,
+  region = excluded.region,
+  faceit_elo = excluded.faceit_elo,
+  leaderboard_position = excluded.leaderboard_position,
   updated_at = CURRENT_TIMESTAMP
-RETURNING steam_id, name, country, faceit_url, avatar, created_at, updated_at;
\ No newline at end of file
+RETURNING steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position;
+
+-- name: GetPlayer :one
+SELECT steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position
+FROM players
+WHERE steam_id = ?;
+
+-- name: ListPlayers :many
+SELECT steam_id, name, country, faceit_url, avatar, created_at, updated_at, region, faceit_elo, leaderboard_position
+FROM players
+WHERE (sqlc.narg(name_prefix) IS NULL OR name LIKE sqlc.narg(name_prefix) || '%' ESCAPE '\')
+  AND (sqlc.narg(country) IS NULL OR country = sqlc.narg(country))
+  AND (sqlc.narg(cursor_name) IS NULL
+    OR name > sqlc.narg(cursor_name)
+    OR (name = sqlc.narg(cursor_name) AND steam_id > sqlc.arg(cursor_steam_id)))
+ORDER BY name, steam_id
+LIMIT sqlc.arg(row_limit);
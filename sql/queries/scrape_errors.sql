@@ -0,0 +1,12 @@
+-- name: CreateScrapeError :exec
+INSERT INTO scrape_errors (run_id, match_url, player_name, column_name, raw_value, message, created_at)
+VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+
+-- name: ListScrapeErrors :many
+SELECT id, run_id, match_url, player_name, column_name, raw_value, message, created_at
+FROM scrape_errors
+WHERE (sqlc.narg(run_id) IS NULL OR run_id = sqlc.narg(run_id))
+  AND (sqlc.narg(column_name) IS NULL OR column_name = sqlc.narg(column_name))
+  AND (sqlc.narg(before_id) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
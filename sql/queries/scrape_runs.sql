@@ -0,0 +1,17 @@
+-- name: CreateScrapeRun :one
+INSERT INTO scrape_runs (started_at, status)
+VALUES (CURRENT_TIMESTAMP, 'running')
+RETURNING id, started_at, finished_at, status, error_message;
+
+-- name: FinishScrapeRun :exec
+UPDATE scrape_runs
+SET finished_at = CURRENT_TIMESTAMP,
+  status = ?,
+  error_message = ?
+WHERE id = ?;
+
+-- name: ListScrapeRuns :many
+SELECT id, started_at, finished_at, status, error_message
+FROM scrape_runs
+ORDER BY id DESC
+LIMIT ?;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE match_players (
+  match_url TEXT NOT NULL REFERENCES matches(match_url) ON DELETE CASCADE,
+  player_name TEXT NOT NULL,
+  steam_id TEXT,
+  team INTEGER NOT NULL,
+  won BOOLEAN NOT NULL,
+  leetify_rating REAL,
+  personal_performance REAL,
+  hltv_rating REAL,
+  kd REAL,
+  adr REAL,
+  aim REAL,
+  utility REAL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (match_url, player_name)
+);
+
+CREATE INDEX match_players_steam_id_idx ON match_players(steam_id);
+
+-- +goose Down
+DROP TABLE match_players;
\ No newline at end of file
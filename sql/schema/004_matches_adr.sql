@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE matches ADD COLUMN w_avg_adr REAL;
+ALTER TABLE matches ADD COLUMN l_avg_adr REAL;
+
+-- +goose Down
+ALTER TABLE matches DROP COLUMN l_avg_adr;
+ALTER TABLE matches DROP COLUMN w_avg_adr;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE scrape_runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  started_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP,
+  status TEXT NOT NULL,
+  error_message TEXT
+);
+
+-- +goose Down
+DROP TABLE scrape_runs;
\ No newline at end of file
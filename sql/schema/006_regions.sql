@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE players ADD COLUMN region TEXT;
+ALTER TABLE players ADD COLUMN faceit_elo INTEGER;
+ALTER TABLE players ADD COLUMN leaderboard_position INTEGER;
+ALTER TABLE matches ADD COLUMN region TEXT;
+
+CREATE INDEX matches_region_idx ON matches(region);
+
+-- +goose Down
+DROP INDEX matches_region_idx;
+
+ALTER TABLE matches DROP COLUMN region;
+ALTER TABLE players DROP COLUMN leaderboard_position;
+ALTER TABLE players DROP COLUMN faceit_elo;
+ALTER TABLE players DROP COLUMN region;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE player_snapshots (
+  steam_id TEXT NOT NULL,
+  captured_at TIMESTAMP NOT NULL,
+  region TEXT NOT NULL,
+  faceit_elo INTEGER NOT NULL,
+  skill_level INTEGER NOT NULL,
+  leaderboard_position INTEGER NOT NULL,
+  PRIMARY KEY (steam_id, captured_at)
+);
+
+-- +goose Down
+DROP TABLE player_snapshots;
\ No newline at end of file
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE players_new (
+  steam_id TEXT PRIMARY KEY CHECK (length(steam_id) = 17 AND steam_id NOT GLOB '*[^0-9]*'),
+  name TEXT NOT NULL CHECK (name <> ''),
+  country TEXT NOT NULL,
+  faceit_url TEXT NOT NULL,
+  avatar TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  region TEXT CHECK (region IN ('EU', 'NA', 'SA', 'OCE', 'SEA')),
+  faceit_elo INTEGER CHECK (faceit_elo >= 0),
+  leaderboard_position INTEGER CHECK (leaderboard_position > 0)
+);
+
+-- rows saved with a malformed steam ID can't be linked to anything, drop them
+INSERT INTO players_new
+SELECT CAST(steam_id AS TEXT), name, country, faceit_url, avatar, created_at, updated_at,
+  region, faceit_elo, NULLIF(leaderboard_position, 0)
+FROM players
+WHERE length(CAST(steam_id AS TEXT)) = 17
+  AND CAST(steam_id AS TEXT) NOT GLOB '*[^0-9]*'
+  AND name <> '';
+
+DROP TABLE players;
+ALTER TABLE players_new RENAME TO players;
+
+-- +goose Down
+CREATE TABLE players_old (
+  steam_id UUID PRIMARY KEY,
+  name TEXT NOT NULL,
+  country TEXT NOT NULL,
+  faceit_url TEXT NOT NULL,
+  avatar TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  region TEXT,
+  faceit_elo INTEGER,
+  leaderboard_position INTEGER
+);
+
+INSERT INTO players_old SELECT * FROM players;
+
+DROP TABLE players;
+ALTER TABLE players_old RENAME TO players;
\ No newline at end of file
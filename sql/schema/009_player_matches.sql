@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE player_matches (
+  steam_id TEXT NOT NULL REFERENCES players(steam_id) ON DELETE CASCADE,
+  match_url TEXT NOT NULL REFERENCES matches(match_url) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (steam_id, match_url)
+);
+
+CREATE INDEX player_matches_match_url_idx ON player_matches(match_url);
+
+-- +goose Down
+DROP TABLE player_matches;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE matches ADD COLUMN map TEXT;
+ALTER TABLE matches ADD COLUMN w_rounds INTEGER;
+ALTER TABLE matches ADD COLUMN l_rounds INTEGER;
+ALTER TABLE matches ADD COLUMN played_at TIMESTAMP;
+ALTER TABLE matches ADD COLUMN data_source TEXT;
+
+CREATE INDEX matches_map_idx ON matches(map);
+CREATE INDEX matches_played_at_idx ON matches(played_at);
+
+-- +goose Down
+DROP INDEX matches_played_at_idx;
+DROP INDEX matches_map_idx;
+
+ALTER TABLE matches DROP COLUMN data_source;
+ALTER TABLE matches DROP COLUMN played_at;
+ALTER TABLE matches DROP COLUMN l_rounds;
+ALTER TABLE matches DROP COLUMN w_rounds;
+ALTER TABLE matches DROP COLUMN map;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- +goose Up
+-- a tied match keeps its two teams in the w_ and l_ columns in scoreboard order
+ALTER TABLE matches ADD COLUMN tie BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE matches DROP COLUMN tie;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE scrape_errors (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  run_id INTEGER REFERENCES scrape_runs(id),
+  match_url TEXT,
+  player_name TEXT,
+  column_name TEXT,
+  raw_value TEXT,
+  message TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX scrape_errors_run_id_idx ON scrape_errors(run_id);
+
+-- +goose Down
+DROP INDEX scrape_errors_run_id_idx;
+DROP TABLE scrape_errors;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE faceit_match_players (
+  match_id TEXT NOT NULL,
+  player_id TEXT NOT NULL,
+  steam_id TEXT,
+  nickname TEXT NOT NULL,
+  team INTEGER NOT NULL,
+  won BOOLEAN NOT NULL,
+  map TEXT,
+  kills INTEGER,
+  deaths INTEGER,
+  assists INTEGER,
+  kd REAL,
+  adr REAL,
+  headshots_pct REAL,
+  finished_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (match_id, player_id)
+);
+
+CREATE INDEX faceit_match_players_steam_id_idx ON faceit_match_players(steam_id);
+
+-- +goose Down
+DROP INDEX faceit_match_players_steam_id_idx;
+DROP TABLE faceit_match_players;
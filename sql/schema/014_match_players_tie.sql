@@ -0,0 +1,9 @@
+-- +goose Up
+-- won is false for both teams of a tied match, tie tells a draw from a loss
+ALTER TABLE match_players ADD COLUMN tie BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE match_players SET tie = TRUE
+WHERE match_url IN (SELECT match_url FROM matches WHERE tie);
+
+-- +goose Down
+ALTER TABLE match_players DROP COLUMN tie;
\ No newline at end of file
@@ -0,0 +1,65 @@
+-- +goose Up
+-- player names aren't unique within a match, key players on their steam ID
+-- and only fall back to the name, within a team, when there is none
+CREATE TABLE match_players_new (
+  match_url TEXT NOT NULL REFERENCES matches(match_url) ON DELETE CASCADE,
+  player_name TEXT NOT NULL,
+  steam_id TEXT,
+  team INTEGER NOT NULL,
+  won BOOLEAN NOT NULL,
+  leetify_rating REAL,
+  personal_performance REAL,
+  hltv_rating REAL,
+  kd REAL,
+  adr REAL,
+  aim REAL,
+  utility REAL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  tie BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX match_players_steam_id_key ON match_players_new(match_url, steam_id)
+WHERE steam_id IS NOT NULL;
+CREATE UNIQUE INDEX match_players_name_key ON match_players_new(match_url, team, player_name)
+WHERE steam_id IS NULL;
+
+-- a player renamed between scrapes of a match was saved twice, the indexes
+-- above make the latest row win
+INSERT OR IGNORE INTO match_players_new
+SELECT match_url, player_name, steam_id, team, won, leetify_rating, personal_performance,
+  hltv_rating, kd, adr, aim, utility, created_at, updated_at, tie
+FROM match_players
+ORDER BY updated_at DESC;
+
+DROP TABLE match_players;
+ALTER TABLE match_players_new RENAME TO match_players;
+
+CREATE INDEX match_players_steam_id_idx ON match_players(steam_id);
+
+-- +goose Down
+CREATE TABLE match_players_old (
+  match_url TEXT NOT NULL REFERENCES matches(match_url) ON DELETE CASCADE,
+  player_name TEXT NOT NULL,
+  steam_id TEXT,
+  team INTEGER NOT NULL,
+  won BOOLEAN NOT NULL,
+  leetify_rating REAL,
+  personal_performance REAL,
+  hltv_rating REAL,
+  kd REAL,
+  adr REAL,
+  aim REAL,
+  utility REAL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  tie BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (match_url, player_name)
+);
+
+INSERT OR IGNORE INTO match_players_old SELECT * FROM match_players;
+
+DROP TABLE match_players;
+ALTER TABLE match_players_old RENAME TO match_players;
+
+CREATE INDEX match_players_steam_id_idx ON match_players(steam_id);
@@ -0,0 +1,43 @@
+-- +goose Up
+-- match validSteamID64, which only saves IDs of individual accounts
+CREATE TABLE players_new (
+  steam_id TEXT PRIMARY KEY CHECK (length(steam_id) = 17 AND steam_id NOT GLOB '*[^0-9]*' AND steam_id GLOB '7656119*'),
+  name TEXT NOT NULL CHECK (name <> ''),
+  country TEXT NOT NULL,
+  faceit_url TEXT NOT NULL,
+  avatar TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  region TEXT CHECK (region IN ('EU', 'NA', 'SA', 'OCE', 'SEA')),
+  faceit_elo INTEGER CHECK (faceit_elo >= 0),
+  leaderboard_position INTEGER CHECK (leaderboard_position > 0)
+);
+
+INSERT INTO players_new
+SELECT * FROM players
+WHERE steam_id GLOB '7656119*';
+
+DELETE FROM player_matches
+WHERE steam_id NOT GLOB '7656119*';
+
+DROP TABLE players;
+ALTER TABLE players_new RENAME TO players;
+
+-- +goose Down
+CREATE TABLE players_old (
+  steam_id TEXT PRIMARY KEY CHECK (length(steam_id) = 17 AND steam_id NOT GLOB '*[^0-9]*'),
+  name TEXT NOT NULL CHECK (name <> ''),
+  country TEXT NOT NULL,
+  faceit_url TEXT NOT NULL,
+  avatar TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  region TEXT CHECK (region IN ('EU', 'NA', 'SA', 'OCE', 'SEA')),
+  faceit_elo INTEGER CHECK (faceit_elo >= 0),
+  leaderboard_position INTEGER CHECK (leaderboard_position > 0)
+);
+
+INSERT INTO players_old SELECT * FROM players;
+
+DROP TABLE players;
+ALTER TABLE players_old RENAME TO players;